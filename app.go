@@ -2,11 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 
 	e "ExcelAnalyzer/bround"
 
@@ -17,6 +17,7 @@ import (
 type App struct {
 	ctx              context.Context
 	analyzedFilePath string
+	config           e.AnalysisConfig
 }
 
 // NewApp creates a new App application struct
@@ -28,6 +29,7 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.config = loadConfig()
 }
 
 func (a *App) shutdown(ctx context.Context) {
@@ -40,43 +42,82 @@ func (a *App) Greet(name string) string {
 }
 
 // AnalyzeExcel analyzes the selected Excel file
-func (a *App) AnalyzeExcel(filePath string) error {
+// period 中留空的日期按数据自动确定。
+// 界面使用宽松模式,无法解析的行写入 "数据问题" 工作表并在返回的汇总中列出
+func (a *App) AnalyzeExcel(filePath string, period e.ReportPeriod) (e.Summary, error) {
 	fmt.Println("待分析文件:", filePath)
-	// 生成新的文件名
-	dir := filepath.Dir(filePath)
-	fileName := filepath.Base(filePath)
-	fileExt := filepath.Ext(fileName)
-	fileNameWithoutExt := fileName[:len(fileName)-len(fileExt)]
-	newFileName := fileNameWithoutExt + "_分析完成" + fileExt
-	a.analyzedFilePath = filepath.Join(dir, newFileName)
-	// 这里调用您现有的Excel分析代码
-	err := e.Main_go(filePath, a.analyzedFilePath, a.ctx)
-	if err != nil {
-		//判断err是否以"数据不足"开头
-		if strings.HasPrefix(err.Error(), "数据不足") {
+	a.analyzedFilePath = ""
+	cfg := a.config
+	opts, err := loadOptions()
+	if err != nil {
+		return e.Summary{}, err
+	}
+	opts.Lenient = true
+	cfg.Load = opts
+	cfg.Period = period
+	// 这里调用您现有的Excel分析代码,输出文件名按报表日期生成
+	summary, err := e.Main_go(filePath, "", cfg, e.NewWailsReporter(a.ctx))
+	if err != nil {
+		//判断是否为数据不足
+		if errors.Is(err, e.ErrInsufficientData) {
 			runtime.EventsEmit(a.ctx, "error", err.Error())
 		}
-		return err
+		return summary, err
 	}
+	a.analyzedFilePath = summary.OutputPath
 	fmt.Println("分析完成:", filePath)
+	return summary, nil
+}
+
+// GetConfig 返回当前的分析设置
+func (a *App) GetConfig() e.AnalysisConfig {
+	return a.config
+}
+
+// DefaultConfig 返回默认的分析设置,供设置面板恢复默认值
+func (a *App) DefaultConfig() e.AnalysisConfig {
+	return e.DefaultAnalysisConfig()
+}
+
+// SaveConfig 保存分析设置到配置目录,之后的分析都使用新设置
+func (a *App) SaveConfig(cfg e.AnalysisConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	dir, err := configDir()
+	if err != nil {
+		return fmt.Errorf("找不到配置目录: %w", err)
+	}
+	if err := e.SaveAnalysisConfig(filepath.Join(dir, "config.json"), cfg); err != nil {
+		return err
+	}
+	a.config = cfg
 	return nil
 }
 
-// SaveExcel 保存分析后的Excel文件
+// saveFilters 是各输出格式在保存对话框中的文件类型,CSV 输出为目录,不在此列
+var saveFilters = map[string]runtime.FileFilter{
+	".xlsx": {DisplayName: "Excel文件 (*.xlsx)", Pattern: "*.xlsx"},
+	".json": {DisplayName: "JSON文件 (*.json)", Pattern: "*.json"},
+}
+
+// SaveExcel 保存分析结果文件;CSV 格式的结果是一个目录,选择文件夹后整个目录复制过去
 func (a *App) SaveExcel() error {
 	if a.analyzedFilePath == "" {
 		return fmt.Errorf("没有可用的分析结果文件")
 	}
+	info, err := os.Stat(a.analyzedFilePath)
+	if err != nil {
+		return fmt.Errorf("找不到分析结果: %w", err)
+	}
+	if info.IsDir() {
+		return a.saveDir()
+	}
 
 	// 打开保存文件对话框
 	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
-		Title: "保存分析结果",
-		Filters: []runtime.FileFilter{
-			{
-				DisplayName: "Excel文件 (*.xlsx)",
-				Pattern:     "*.xlsx",
-			},
-		},
+		Title:           "保存分析结果",
+		Filters:         []runtime.FileFilter{saveFilters[filepath.Ext(a.analyzedFilePath)]},
 		DefaultFilename: filepath.Base(a.analyzedFilePath),
 	})
 
@@ -98,6 +139,33 @@ func (a *App) SaveExcel() error {
 	return nil
 }
 
+// saveDir 把 CSV 结果目录复制到用户选择的文件夹下
+func (a *App) saveDir() error {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "选择保存分析结果的文件夹",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return fmt.Errorf("打开保存对话框失败: %w", err)
+	}
+	if dir == "" {
+		return nil // 用户取消了保存操作
+	}
+
+	// 目标目录里上次保存的 CSV 先删掉,否则这次没有生成的报表会残留下来;
+	// 只删除清单中列出的文件,清单随结果目录一起复制过去
+	target := filepath.Join(dir, filepath.Base(a.analyzedFilePath))
+	if err := e.RemoveCSVReport(target); err != nil {
+		return fmt.Errorf("清理旧文件失败: %w", err)
+	}
+	if err := copyDir(a.analyzedFilePath, target); err != nil {
+		return fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	fmt.Printf("文件已保存到: %s\n", target)
+	return nil
+}
+
 // OpenFileDialog opens a file dialog and returns the selected file path
 func (a *App) OpenFileDialog() (string, error) {
 	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
@@ -115,6 +183,71 @@ func (a *App) OpenFileDialog() (string, error) {
 	return filePath, nil
 }
 
+// configDir 返回保存本程序配置的目录
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ExcelAnalyzer"), nil
+}
+
+// loadConfig 读取配置目录中的分析设置 config.json,读取失败时使用默认设置
+func loadConfig() e.AnalysisConfig {
+	dir, err := configDir()
+	if err != nil {
+		return e.DefaultAnalysisConfig()
+	}
+	path := filepath.Join(dir, "config.json")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return e.DefaultAnalysisConfig()
+	}
+	cfg, err := e.LoadAnalysisConfig(path)
+	if err != nil {
+		fmt.Println("读取分析设置失败,使用默认设置:", err)
+		return e.DefaultAnalysisConfig()
+	}
+	return cfg
+}
+
+// loadOptions 读取配置目录中的列配置 columns.json,没有该文件时使用默认列配置
+func loadOptions() (e.LoadOptions, error) {
+	opts := e.DefaultLoadOptions()
+	dir, err := configDir()
+	if err != nil {
+		return opts, nil
+	}
+	path := filepath.Join(dir, "columns.json")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return opts, nil
+	}
+	opts.Columns, err = e.LoadColumnMapping(path)
+	if err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// copyDir 把 src 目录下的文件复制到 dst 目录,不处理子目录
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := copyFile(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // copyFile 复制文件的辅助函数
 func copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
@@ -0,0 +1,159 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// abcClasses 是 ABC 分类的类别,按重要程度排列
+var abcClasses = []string{"A", "B", "C"}
+
+// StyleClass 是一个货号在统计范围内的销量、累计占比和 ABC 类别
+type StyleClass struct {
+	StyleID    string  `json:"styleId"`
+	TotalSales int     `json:"totalSales"`
+	Share      float64 `json:"share"`
+	Cumulative float64 `json:"cumulative"` // 按销量降序排列后到该货号为止的累计占比
+	Class      string  `json:"class"`
+}
+
+// styleTotals 汇总统计范围内每个货号的总销量
+func styleTotals(records []SaleRecord, p period) map[string]int {
+	totals := make(map[string]int)
+	for _, record := range records {
+		if p.contains(record.Date) {
+			totals[record.ProductID] += record.Quantity
+		}
+	}
+	return totals
+}
+
+// classifyStyles 按销量降序累计占比分类:累计占比达到 aPercent 之前(含跨过该线的货号)为 A,
+// 达到 bPercent 之前为 B,其余为 C
+func classifyStyles(totals map[string]int, aPercent, bPercent int) []StyleClass {
+	classes := make([]StyleClass, 0, len(totals))
+	sum := 0
+	for styleID, total := range totals {
+		classes = append(classes, StyleClass{StyleID: styleID, TotalSales: total})
+		sum += total
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if classes[i].TotalSales != classes[j].TotalSales {
+			return classes[i].TotalSales > classes[j].TotalSales
+		}
+		return classes[i].StyleID < classes[j].StyleID
+	})
+
+	cumulative := 0
+	for i := range classes {
+		// 前一个货号结束时的累计占比决定当前货号的类别
+		before := 0.0
+		if sum > 0 {
+			before = float64(cumulative) * 100 / float64(sum)
+		}
+		cumulative += classes[i].TotalSales
+		if sum > 0 {
+			classes[i].Share = float64(classes[i].TotalSales) / float64(sum)
+			classes[i].Cumulative = float64(cumulative) / float64(sum)
+		}
+		switch {
+		case before < float64(aPercent):
+			classes[i].Class = "A"
+		case before < float64(bPercent):
+			classes[i].Class = "B"
+		default:
+			classes[i].Class = "C"
+		}
+	}
+	return classes
+}
+
+// styleClassMap 返回 货号 -> 类别,供 销量 和 月货号 表增加分类列
+func styleClassMap(records []SaleRecord, p period, cfg AnalysisConfig) map[string]string {
+	classMap := make(map[string]string)
+	for _, class := range classifyStyles(styleTotals(records, p), cfg.AbcAPercent, cfg.AbcBPercent) {
+		classMap[class.StyleID] = class.Class
+	}
+	return classMap
+}
+
+func getAbcReport(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]StyleClass, error) {
+	// 1. 按统计范围内的总销量分类
+	progress.stage(weightAnalyze)
+	classes := classifyStyles(styleTotals(records, p), cfg.AbcAPercent, cfg.AbcBPercent)
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  A:累计销量占比前%d%%  B:%d%%-%d%%  C:其余", p.rangeCaption(), cfg.AbcAPercent, cfg.AbcAPercent, cfg.AbcBPercent)
+	err := createAbcReport(f, sheetName, classes, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return classes, nil
+}
+
+func createAbcReport(f *excelize.File, sheetName string, classes []StyleClass, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	// 汇总:每类的货号数和销量占比
+	titles := []string{"类别", "货号数", "货号占比", "销量", "销量占比"}
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+	counts := make(map[string]int)
+	sales := make(map[string]int)
+	totalSales := 0
+	for _, class := range classes {
+		counts[class.Class]++
+		sales[class.Class] += class.TotalSales
+		totalSales += class.TotalSales
+	}
+	for i, class := range abcClasses {
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), class)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), counts[class])
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), sales[class])
+		if len(classes) > 0 {
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), float64(counts[class])/float64(len(classes)))
+		}
+		if totalSales > 0 {
+			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), float64(sales[class])/float64(totalSales))
+		}
+	}
+	summaryEnd := firstRow + len(abcClasses) - 1
+	f.SetCellStyle(sheetName, fmt.Sprintf("C%d", firstRow), fmt.Sprintf("C%d", summaryEnd), percentStyle)
+	f.SetCellStyle(sheetName, fmt.Sprintf("E%d", firstRow), fmt.Sprintf("E%d", summaryEnd), percentStyle)
+
+	// 明细写在汇总下方,空一行
+	detailHeader := summaryEnd + 2
+	for col, title := range []string{"货号", "销量", "占比", "累计占比", "类别"} {
+		cell, _ := excelize.CoordinatesToCellName(col+1, detailHeader)
+		f.SetCellValue(sheetName, cell, title)
+	}
+	for i, class := range classes {
+		progress.step(i, len(classes), "统计 ABC 分类:正在写入数据")
+		row := detailHeader + 1 + i
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), class.StyleID)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), class.TotalSales)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), class.Share)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), class.Cumulative)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), class.Class)
+	}
+	if len(classes) > 0 {
+		lastRow := detailHeader + len(classes)
+		f.SetCellStyle(sheetName, fmt.Sprintf("C%d", detailHeader+1), fmt.Sprintf("D%d", lastRow), percentStyle)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package bround
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClassifyStyles(t *testing.T) {
+	type class struct {
+		styleID    string
+		class      string
+		cumulative float64
+	}
+	tests := []struct {
+		name               string
+		totals             map[string]int
+		aPercent, bPercent int
+		want               []class
+	}{
+		{"跨过A线的货号仍为A", map[string]int{"a": 50, "b": 30, "c": 15, "d": 5}, 70, 90,
+			[]class{{"a", "A", 0.5}, {"b", "A", 0.8}, {"c", "B", 0.95}, {"d", "C", 1}}},
+		{"销量相同按货号排序", map[string]int{"y": 10, "x": 10}, 50, 80,
+			[]class{{"x", "A", 0.5}, {"y", "B", 1}}},
+		{"总销量为0全部为A", map[string]int{"b": 0, "a": 0}, 70, 90,
+			[]class{{"a", "A", 0}, {"b", "A", 0}}},
+		{"没有货号", map[string]int{}, 70, 90, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyStyles(tt.totals, tt.aPercent, tt.bPercent)
+			if len(got) != len(tt.want) {
+				t.Fatalf("得到 %+v,期望 %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].StyleID != want.styleID || got[i].Class != want.class || math.Abs(got[i].Cumulative-want.cumulative) > 1e-9 {
+					t.Errorf("第 %d 个 = %+v,期望 %+v", i+1, got[i], want)
+				}
+			}
+		})
+	}
+}
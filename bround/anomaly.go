@@ -0,0 +1,193 @@
+package bround
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	anomalyBaselineDays = 28 // 每天与之前最多这么多天比较
+	anomalyMinBaseline  = 7  // 之前不足这么多天时不判断
+	anomalyMinActive    = 4  // 之前有销量的天数不足时不判断,零星配货的货号不会每次都被当成异常
+)
+
+// Anomaly 是某个货号、客户或货号+客户在某一天明显偏离自身近期水平的销量
+type Anomaly struct {
+	Kind     string    `json:"kind"` // 货号 / 客户 / 货号+客户
+	Name     string    `json:"name"` // 货号+客户 为 货号|客户
+	Date     time.Time `json:"date"`
+	Quantity int       `json:"quantity"`
+	Baseline float64   `json:"baseline"` // 之前若干天的中位数
+	Score    float64   `json:"score"`    // 稳健 z 分数,正数偏高,负数偏低
+}
+
+// detectAnomalies 逐日计算 dailySales 中每个名称相对之前 anomalyBaselineDays 天的稳健 z 分数(基于中位数和 MAD),
+// 绝对值达到 threshold 的记为异常
+func detectAnomalies(kind string, dailySales map[string]map[string]int, window dateWindow, threshold float64) []Anomaly {
+	var anomalies []Anomaly
+	for name, sales := range dailySales {
+		series := dailySeries(sales, window)
+		for i := anomalyMinBaseline; i < len(series); i++ {
+			start := i - anomalyBaselineDays
+			if start < 0 {
+				start = 0
+			}
+			baseline := series[start:i]
+			score, median, ok := robustZScore(series[i], baseline)
+			if !ok || math.Abs(score) < threshold {
+				continue
+			}
+			anomalies = append(anomalies, Anomaly{
+				Kind:     kind,
+				Name:     name,
+				Date:     window.from.AddDate(0, 0, i),
+				Quantity: int(series[i]),
+				Baseline: median,
+				Score:    score,
+			})
+		}
+	}
+	return anomalies
+}
+
+// robustZScore 返回 value 相对 baseline 的稳健 z 分数。baseline 中有销量的天数不足 anomalyMinActive 时无法判断;
+// MAD 为 0 时,中位数大于 0 才退回平均绝对偏差,否则无法判断
+func robustZScore(value float64, baseline []float64) (score, med float64, ok bool) {
+	med = median(baseline)
+	active := 0
+	for _, v := range baseline {
+		if v != 0 {
+			active++
+		}
+	}
+	if active < anomalyMinActive {
+		return 0, med, false
+	}
+	deviations := make([]float64, len(baseline))
+	for i, v := range baseline {
+		deviations[i] = math.Abs(v - med)
+	}
+	if mad := median(deviations); mad > 0 {
+		return 0.6745 * (value - med) / mad, med, true
+	}
+	if meanDev := mean(deviations); med > 0 && meanDev > 0 {
+		return (value - med) / (1.2533 * meanDev), med, true
+	}
+	return 0, med, false
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// anomalyCells 把异常转成 名称|日期(2006-01-02) 的集合,用来在逐日表格中标出单元格
+func anomalyCells(anomalies []Anomaly) map[string]bool {
+	cells := make(map[string]bool, len(anomalies))
+	for _, anomaly := range anomalies {
+		cells[anomaly.Name+"|"+anomaly.Date.Format("2006-01-02")] = true
+	}
+	return cells
+}
+
+// newAnomalyStyle 是逐日表格中异常单元格的样式
+func newAnomalyStyle(f *excelize.File) (int, error) {
+	return f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"FFC7CE"}, Pattern: 1},
+		Font: &excelize.Font{Color: "9C0006"},
+	})
+}
+
+func getAnomalyReport(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]Anomaly, error) {
+	window := dateWindow{from: p.from, to: p.to}
+
+	// 1. 分别检查货号、客户和货号+客户的逐日销量,两次汇总平分分析阶段的进度
+	progress.stage(weightAnalyze - 1)
+	styleMap, _ := aggregateStyleSales(records, p, "统计 异常:正在分析货号", progress)
+	styleDaily := make(map[string]map[string]int, len(styleMap))
+	for styleID, report := range styleMap {
+		styleDaily[styleID] = report.DailySales
+	}
+	progress.stage(1)
+	salesMap := aggregateStyleCustomerSales(records, p, "统计 异常:正在分析客户", progress)
+	anomalies := detectAnomalies("货号", styleDaily, window, cfg.AnomalyScore)
+	anomalies = append(anomalies, detectAnomalies("客户", customerDailySales(salesMap), window, cfg.AnomalyScore)...)
+	anomalies = append(anomalies, detectAnomalies("货号+客户", styleCustomerDailySales(salesMap), window, cfg.AnomalyScore)...)
+
+	// 最近的在前,同一天按偏离程度排序
+	sort.Slice(anomalies, func(i, j int) bool {
+		a, b := anomalies[i], anomalies[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.After(b.Date)
+		}
+		if math.Abs(a.Score) != math.Abs(b.Score) {
+			return math.Abs(a.Score) > math.Abs(b.Score)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  与之前最多%d天的中位数比较,稳健 z 分数绝对值达到 %.1f 记为异常;月货号表和月货号+客户表中对应的单元格标红",
+		p.rangeCaption(), anomalyBaselineDays, cfg.AnomalyScore)
+	err := createAnomalyReport(f, sheetName, anomalies, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+func createAnomalyReport(f *excelize.File, sheetName string, anomalies []Anomaly, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	titles := []string{"类型", "名称", "日期", "销量", "基线中位数", "偏离分数", "方向"}
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	decimalStyle, err := f.NewStyle(&excelize.Style{NumFmt: 2}) // 0.00
+	if err != nil {
+		return err
+	}
+
+	for i, anomaly := range anomalies {
+		progress.step(i, len(anomalies), "统计 异常:正在写入数据")
+		row := i + firstRow
+		direction := "偏高"
+		if anomaly.Score < 0 {
+			direction = "偏低"
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), anomaly.Kind)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), anomaly.Name)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), anomaly.Date.Format("2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), anomaly.Quantity)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), anomaly.Baseline)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), anomaly.Score)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), direction)
+	}
+	if len(anomalies) > 0 {
+		lastRow := firstRow + len(anomalies) - 1
+		f.SetCellStyle(sheetName, fmt.Sprintf("E%d", firstRow), fmt.Sprintf("F%d", lastRow), decimalStyle)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package bround
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRobustZScore(t *testing.T) {
+	steady := []float64{10, 12, 11, 13, 9, 10, 11} // 中位数 11,MAD 1
+	tests := []struct {
+		name      string
+		value     float64
+		baseline  []float64
+		wantScore float64
+		wantMed   float64
+		wantOK    bool
+	}{
+		{"明显偏高", 30, steady, 0.6745 * 19, 11, true},
+		{"明显偏低", 0, steady, 0.6745 * -11, 11, true},
+		{"与中位数相同", 11, steady, 0, 11, true},
+		{"MAD为0时用平均绝对偏差", 20, []float64{10, 10, 10, 10, 10, 14, 14}, 10 / (1.2533 * 8.0 / 7), 10, true},
+		{"销量稀疏不判断", 5, []float64{0, 0, 0, 0, 0, 0, 5}, 0, 0, false},
+		{"有销量的天数刚好不足", 20, []float64{0, 0, 0, 0, 5, 5, 5}, 0, 0, false},
+		{"中位数为0且MAD为0不判断", 20, []float64{0, 0, 0, 0, 0, 3, 4, 5, 6}, 0, 0, false},
+		{"每天销量相同不判断", 20, repeat(10, 7), 0, 10, false},
+		{"没有基线", 20, nil, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, med, ok := robustZScore(tt.value, tt.baseline)
+			if ok != tt.wantOK || med != tt.wantMed || math.Abs(score-tt.wantScore) > 1e-9 {
+				t.Errorf("robustZScore(%v, %v) = %v, %v, %v,期望 %v, %v, %v",
+					tt.value, tt.baseline, score, med, ok, tt.wantScore, tt.wantMed, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetAnomalyReportKinds(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	// 货号 A 只卖给客户甲,前14天在 9、11 之间波动,最后一天突增到 100
+	var records []SaleRecord
+	for d := 1; d <= 14; d++ {
+		records = append(records, SaleRecord{Date: day(d).Add(9 * time.Hour), ProductID: "A", Customer: "甲", Quantity: 9 + 2*(d%2)})
+	}
+	records = append(records, SaleRecord{Date: day(15).Add(9 * time.Hour), ProductID: "A", Customer: "甲", Quantity: 100})
+
+	f := excelize.NewFile()
+	defer f.Close()
+	p := period{asOf: day(15), from: day(1), to: day(15)}
+	anomalies, err := getAnomalyReport(f, "异常", records, p, DefaultAnalysisConfig(), newProgressTracker(nil, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		kind, name string
+	}{
+		{"货号", "A"},
+		{"客户", "甲"},
+		{"货号+客户", "A|甲"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			for _, anomaly := range anomalies {
+				if anomaly.Kind == tt.kind && anomaly.Name == tt.name && anomaly.Date.Equal(day(15)) && anomaly.Score > 0 {
+					return
+				}
+			}
+			t.Errorf("得到 %+v,期望包含 %s %s 在 8月15日偏高", anomalies, tt.kind, tt.name)
+		})
+	}
+}
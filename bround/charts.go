@@ -0,0 +1,161 @@
+package bround
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ChartRight = "right" // 图表放在数据右侧
+	ChartBelow = "below" // 图表放在数据下方
+)
+
+// ChartPlacements 列出 AnalysisConfig.ChartPlacement 的可选值
+var ChartPlacements = []string{ChartRight, ChartBelow}
+
+// reportFirstRow 是 writeReportHeader 之后数据的起始行,列标题在上一行
+const reportFirstRow = 3
+
+// chartAnchor 按 placement 返回图表左上角的单元格,位置根据工作表已写入的行列计算
+func chartAnchor(f *excelize.File, sheetName, placement string) (string, error) {
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return "", err
+	}
+	lastCol := 0
+	for _, row := range rows {
+		if len(row) > lastCol {
+			lastCol = len(row)
+		}
+	}
+	if placement == ChartBelow {
+		return excelize.CoordinatesToCellName(1, len(rows)+2)
+	}
+	return excelize.CoordinatesToCellName(lastCol+2, 2)
+}
+
+// rangeRef 返回 'sheet'!$A$1:$B$2 形式的引用
+func rangeRef(sheetName string, fromCol, fromRow, toCol, toRow int) string {
+	from, _ := excelize.CoordinatesToCellName(fromCol, fromRow, true)
+	to, _ := excelize.CoordinatesToCellName(toCol, toRow, true)
+	return fmt.Sprintf("'%s'!%s:%s", sheetName, from, to)
+}
+
+// chartRows 返回前 topN 行数据的行数,topN 为 0 表示不画图
+func chartRows(rows, topN int) int {
+	if rows > topN {
+		return topN
+	}
+	return rows
+}
+
+// addTopBarChart 在 销量 表中画当日销量前 N 的货号,货号在 A 列、当日销量在 B 列
+func addTopBarChart(f *excelize.File, sheetName string, rows int, cfg AnalysisConfig) error {
+	n := chartRows(rows, cfg.ChartTopN)
+	if n == 0 {
+		return nil
+	}
+	anchor, err := chartAnchor(f, sheetName, cfg.ChartPlacement)
+	if err != nil {
+		return err
+	}
+	lastRow := reportFirstRow + n - 1
+	varyColors := false
+	return f.AddChart(sheetName, anchor, &excelize.Chart{
+		Type:       excelize.Bar,
+		VaryColors: &varyColors,
+		Series: []excelize.ChartSeries{{
+			Name:       fmt.Sprintf("'%s'!$B$2", sheetName),
+			Categories: rangeRef(sheetName, 1, reportFirstRow, 1, lastRow),
+			Values:     rangeRef(sheetName, 2, reportFirstRow, 2, lastRow),
+		}},
+		Title:  []excelize.RichTextRun{{Text: fmt.Sprintf("当日销量前%d货号", n)}},
+		Legend: excelize.ChartLegend{Position: "none"},
+		// 条形图从下往上画,反转后第一名在最上面
+		XAxis: excelize.ChartAxis{ReverseOrder: true},
+	})
+}
+
+// addStyleLineChart 在 月货号 表中为前 N 个货号各画一条逐日销量折线,日期在第 2 列起的 dates 列
+func addStyleLineChart(f *excelize.File, sheetName string, rows, dates int, cfg AnalysisConfig) error {
+	n := chartRows(rows, cfg.ChartTopN)
+	if n == 0 || dates == 0 {
+		return nil
+	}
+	anchor, err := chartAnchor(f, sheetName, cfg.ChartPlacement)
+	if err != nil {
+		return err
+	}
+	var series []excelize.ChartSeries
+	for row := reportFirstRow; row < reportFirstRow+n; row++ {
+		series = append(series, excelize.ChartSeries{
+			Name:       fmt.Sprintf("'%s'!$A$%d", sheetName, row),
+			Categories: rangeRef(sheetName, 2, reportFirstRow-1, dates+1, reportFirstRow-1),
+			Values:     rangeRef(sheetName, 2, row, dates+1, row),
+		})
+	}
+	return f.AddChart(sheetName, anchor, &excelize.Chart{
+		Type:         excelize.Line,
+		Series:       series,
+		Title:        []excelize.RichTextRun{{Text: fmt.Sprintf("前%d货号逐日销量", n)}},
+		Legend:       excelize.ChartLegend{Position: "right"},
+		Dimension:    excelize.ChartDimension{Width: 720, Height: 360},
+		ShowBlanksAs: "zero",
+	})
+}
+
+// pieDataTitle 是饼图数据块的第一个列标题。这块数据只为画图写在表格下方,导出 CSV 时跳过
+const pieDataTitle = "饼图客户"
+
+// addCustomerPieChart 在 客户排名 表中画本月销量前 N 的客户及 "其他" 的占比,占比与 本月占比 列一致。
+// 饼图数据另写在表格最下方,stats 需已按本月销量降序排列
+func addCustomerPieChart(f *excelize.File, sheetName string, stats []CustomerRankStat, monthTotal int, cfg AnalysisConfig) error {
+	var top []CustomerRankStat
+	for _, stat := range stats {
+		if stat.MonthlySales > 0 {
+			top = append(top, stat)
+		}
+	}
+	n := chartRows(len(top), cfg.ChartTopN)
+	if n == 0 {
+		return nil
+	}
+
+	// 空一行后写 客户|本月销量,前 N 之外的客户合并为 "其他"
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return err
+	}
+	header := len(rows) + 2
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", header), pieDataTitle)
+	f.SetCellValue(sheetName, fmt.Sprintf("B%d", header), "本月销量")
+	others := monthTotal
+	for i, stat := range top[:n] {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", header+1+i), stat.Customer)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", header+1+i), stat.MonthlySales)
+		others -= stat.MonthlySales
+	}
+	lastRow := header + n
+	if others > 0 {
+		lastRow++
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", lastRow), "其他")
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", lastRow), others)
+	}
+
+	anchor, err := chartAnchor(f, sheetName, cfg.ChartPlacement)
+	if err != nil {
+		return err
+	}
+	return f.AddChart(sheetName, anchor, &excelize.Chart{
+		Type: excelize.Pie,
+		Series: []excelize.ChartSeries{{
+			Name:       fmt.Sprintf("'%s'!$B$%d", sheetName, header),
+			Categories: rangeRef(sheetName, 1, header+1, 1, lastRow),
+			Values:     rangeRef(sheetName, 2, header+1, 2, lastRow),
+		}},
+		Title:    []excelize.RichTextRun{{Text: fmt.Sprintf("本月前%d客户销量占比", n)}},
+		Legend:   excelize.ChartLegend{Position: "right"},
+		PlotArea: excelize.ChartPlotArea{ShowPercent: true},
+	})
+}
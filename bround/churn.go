@@ -0,0 +1,150 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ChurnStat 是一个可能流失的客户
+type ChurnStat struct {
+	Customer        string    `json:"customer"`
+	Dormant         bool      `json:"dormant"`         // 之前经常配货,最近 N 天没有配货
+	LastDate        time.Time `json:"lastDate"`        // 最后一次配货的日期
+	IdleDays        int       `json:"idleDays"`        // 最后一次配货到报表日期的天数
+	ActiveDays      int       `json:"activeDays"`      // 统计范围内有配货的天数
+	WeeklySales     int       `json:"weeklySales"`     // 最近7天销量
+	PrevWeeklySales int       `json:"prevWeeklySales"` // 再往前7天的销量
+}
+
+// WeeklyChange 返回最近7天相对前7天的变化率,前7天没有销量时 ok 为 false
+func (s ChurnStat) WeeklyChange() (float64, bool) {
+	return growthRate(s.WeeklySales, s.PrevWeeklySales)
+}
+
+func getChurnReport(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]ChurnStat, error) {
+	// 报表日期之后的数据不参与判断
+	p.to = p.asOf
+
+	// 1. 找出沉睡和销量下滑的客户
+	progress.stage(weightAnalyze)
+	stats := calculateChurnStats(records, p, cfg, progress)
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  沉睡:此前至少%d天有配货且最近%d天没有配货  下滑:最近7天销量比前7天下降超过%d%%",
+		p.rangeCaption(), cfg.ChurnMinActiveDays, cfg.ChurnDormantDays, cfg.ChurnWeeklyDropPercent)
+	err := createChurnReport(f, sheetName, stats, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func calculateChurnStats(records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) []ChurnStat {
+	// 复用 月货号+客户 的汇总结果,再按客户合并各货号的逐日销量
+	salesMap := aggregateStyleCustomerSales(records, p, "统计 客户流失:正在分析数据", progress)
+	customerDaily := customerDailySales(salesMap)
+
+	dormantSince := p.asOf.AddDate(0, 0, -cfg.ChurnDormantDays)
+	week := dateWindow{from: p.asOf.AddDate(0, 0, -6), to: p.asOf}
+	prevWeek := dateWindow{from: p.asOf.AddDate(0, 0, -13), to: p.asOf.AddDate(0, 0, -7)}
+
+	var stats []ChurnStat
+	for customer, dailySales := range customerDaily {
+		stat := ChurnStat{Customer: customer}
+		for dateStr, quantity := range dailySales {
+			if quantity <= 0 {
+				continue
+			}
+			date, _ := time.ParseInLocation("2006-01-02", dateStr, p.asOf.Location())
+			stat.ActiveDays++
+			if date.After(stat.LastDate) {
+				stat.LastDate = date
+			}
+			if week.contains(date) {
+				stat.WeeklySales += quantity
+			}
+			if prevWeek.contains(date) {
+				stat.PrevWeeklySales += quantity
+			}
+		}
+		if stat.ActiveDays == 0 {
+			continue
+		}
+		stat.IdleDays = int(p.asOf.Sub(stat.LastDate).Hours() / 24)
+		// 最近 N 天都没有配货时,有配货的天数都在这之前
+		stat.Dormant = !stat.LastDate.After(dormantSince) && stat.ActiveDays >= cfg.ChurnMinActiveDays
+
+		dropping := false
+		if change, ok := stat.WeeklyChange(); ok {
+			dropping = -change*100 > float64(cfg.ChurnWeeklyDropPercent)
+		}
+		if stat.Dormant || dropping {
+			stats = append(stats, stat)
+		}
+	}
+
+	// 沉睡客户在前,按未配货天数降序;下滑客户按下降幅度降序
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Dormant != b.Dormant {
+			return a.Dormant
+		}
+		if a.Dormant && a.IdleDays != b.IdleDays {
+			return a.IdleDays > b.IdleDays
+		}
+		changeA, _ := a.WeeklyChange()
+		changeB, _ := b.WeeklyChange()
+		if changeA != changeB {
+			return changeA < changeB
+		}
+		return a.Customer < b.Customer
+	})
+	return stats
+}
+
+func createChurnReport(f *excelize.File, sheetName string, stats []ChurnStat, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	titles := []string{"客户", "类型", "最后配货日期", "未配货天数", "配货天数", "7日销量", "前7日销量", "七日变化率"}
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	for i, stat := range stats {
+		progress.step(i, len(stats), "统计 客户流失:正在写入数据")
+		row := i + firstRow
+		kind := "下滑"
+		if stat.Dormant {
+			kind = "沉睡"
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), stat.Customer)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), kind)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), stat.LastDate.Format("2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), stat.IdleDays)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), stat.ActiveDays)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), stat.WeeklySales)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), stat.PrevWeeklySales)
+		if change, ok := stat.WeeklyChange(); ok {
+			f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), change)
+		}
+	}
+	if len(stats) > 0 {
+		lastRow := firstRow + len(stats) - 1
+		f.SetCellStyle(sheetName, fmt.Sprintf("H%d", firstRow), fmt.Sprintf("H%d", lastRow), percentStyle)
+	}
+
+	return nil
+}
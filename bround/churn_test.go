@@ -0,0 +1,50 @@
+package bround
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateChurnStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	asOf := day(31)
+	p := period{asOf: asOf, from: day(1), to: asOf}
+	cfg := DefaultAnalysisConfig() // 至少3天有配货、7天未配货为沉睡,下降超过50%为下滑
+	// 最近7天为 8/25-8/31,前7天为 8/18-8/24,沉睡的界线是 8/24
+	tests := []struct {
+		name        string
+		daily       map[int]int // 8月几日 -> 销量
+		wantListed  bool
+		wantDormant bool
+		wantIdle    int
+	}{
+		{"最后配货正好在N天前算沉睡", map[int]int{20: 1, 22: 1, 24: 1}, true, true, 7},
+		{"最后配货在N-1天前不算沉睡", map[int]int{20: 1, 22: 1, 25: 2}, false, false, 0},
+		{"配货天数不足不算沉睡", map[int]int{1: 5, 2: 5}, false, false, 0},
+		{"配货数量为0的日子不算配货", map[int]int{10: 1, 12: 1, 24: 1, 27: 0}, true, true, 7},
+		{"正好下降50%不算下滑", map[int]int{20: 10, 30: 5}, false, false, 0},
+		{"下降超过50%算下滑", map[int]int{20: 10, 30: 4}, true, false, 1},
+		{"前7天没有销量不算下滑", map[int]int{10: 10, 30: 1}, false, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []SaleRecord
+			for d, quantity := range tt.daily {
+				records = append(records, SaleRecord{Date: day(d).Add(9 * time.Hour), ProductID: "A", Customer: "客户", Quantity: quantity})
+			}
+			stats := calculateChurnStats(records, p, cfg, newProgressTracker(nil, 1))
+			if !tt.wantListed {
+				if len(stats) != 0 {
+					t.Errorf("得到 %+v,期望不列出", stats)
+				}
+				return
+			}
+			if len(stats) != 1 {
+				t.Fatalf("得到 %+v,期望列出1个客户", stats)
+			}
+			if stats[0].Dormant != tt.wantDormant || stats[0].IdleDays != tt.wantIdle {
+				t.Errorf("得到 %+v,期望沉睡=%v、未配货 %d 天", stats[0], tt.wantDormant, tt.wantIdle)
+			}
+		})
+	}
+}
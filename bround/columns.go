@@ -0,0 +1,114 @@
+package bround
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ColumnSpec 描述一个字段在源数据中的位置:优先按表头名称匹配,找不到时退回到固定列号
+type ColumnSpec struct {
+	Headers []string `json:"headers"` // 可接受的表头名称,按顺序匹配
+	Index   int      `json:"index"`   // 找不到表头时使用的列号(从 0 开始),-1 表示必须匹配表头
+}
+
+// ColumnMapping 是源数据各字段的列配置,可以保存为 JSON 文件在不同导出格式间复用
+type ColumnMapping struct {
+	Date     ColumnSpec `json:"date"`
+	Customer ColumnSpec `json:"customer"`
+	Product  ColumnSpec `json:"product"`
+	Quantity ColumnSpec `json:"quantity"`
+}
+
+// DefaultColumnMapping 对应 ERP 当前的导出格式
+func DefaultColumnMapping() ColumnMapping {
+	return ColumnMapping{
+		Date:     ColumnSpec{Headers: []string{"日期", "配货日期", "单据日期"}, Index: 0},
+		Customer: ColumnSpec{Headers: []string{"客户", "客户名称"}, Index: 2},
+		Product:  ColumnSpec{Headers: []string{"货号", "款号"}, Index: 3},
+		Quantity: ColumnSpec{Headers: []string{"配货数量"}, Index: 8},
+	}
+}
+
+// LoadColumnMapping 读取 JSON 格式的列配置,文件中没有写的字段保持默认值
+func LoadColumnMapping(path string) (ColumnMapping, error) {
+	mapping := DefaultColumnMapping()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mapping, fmt.Errorf("读取列配置失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return mapping, fmt.Errorf("解析列配置 %s 失败: %w", path, err)
+	}
+	return mapping, nil
+}
+
+// SaveColumnMapping 把列配置写成 JSON 文件
+func SaveColumnMapping(path string, mapping ColumnMapping) error {
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("保存列配置失败: %w", err)
+	}
+	return nil
+}
+
+// columnIndexes 是按表头行解析出的实际列号
+type columnIndexes struct {
+	date, customer, product, quantity int
+	minLen                            int      // 数据行至少需要的单元格数
+	warnings                          []string // 找不到表头、按固定列号读取的字段
+}
+
+// resolve 在表头行中查找各字段所在的列
+func (m ColumnMapping) resolve(header []string) (columnIndexes, error) {
+	var cols columnIndexes
+	fields := []struct {
+		name string
+		spec ColumnSpec
+		dst  *int
+	}{
+		{"日期", m.Date, &cols.date},
+		{"客户", m.Customer, &cols.customer},
+		{"货号", m.Product, &cols.product},
+		{"配货数量", m.Quantity, &cols.quantity},
+	}
+	for _, field := range fields {
+		index, warning, err := field.spec.resolve(field.name, header)
+		if err != nil {
+			return cols, err
+		}
+		*field.dst = index
+		if warning != "" {
+			cols.warnings = append(cols.warnings, warning)
+		}
+	}
+	for _, index := range []int{cols.date, cols.customer, cols.product, cols.quantity} {
+		if index+1 > cols.minLen {
+			cols.minLen = index + 1
+		}
+	}
+	return cols, nil
+}
+
+// resolve 返回字段所在的列,找不到表头而使用固定列号时同时返回提示
+func (s ColumnSpec) resolve(field string, header []string) (int, string, error) {
+	for _, name := range s.Headers {
+		for i, cell := range header {
+			if strings.TrimSpace(cell) == name {
+				return i, "", nil
+			}
+		}
+	}
+	if s.Index >= 0 {
+		warning := ""
+		if len(s.Headers) > 0 {
+			warning = fmt.Sprintf("未找到表头 %s,使用第 %d 列作为%s", strings.Join(s.Headers, "/"), s.Index+1, field)
+		}
+		return s.Index, warning, nil
+	}
+	return 0, "", fmt.Errorf("源文件缺少%s列: 找不到表头 %s", field, strings.Join(s.Headers, "/"))
+}
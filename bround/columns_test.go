@@ -0,0 +1,83 @@
+package bround
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColumnSpecResolve(t *testing.T) {
+	header := []string{"单据日期", "仓库", " 客户名称 ", "货号"}
+	tests := []struct {
+		name        string
+		spec        ColumnSpec
+		wantIndex   int
+		wantWarning string
+		wantErr     bool
+	}{
+		{"按表头名称匹配", ColumnSpec{Headers: []string{"客户", "客户名称"}, Index: 0}, 2, "", false},
+		{"按配置顺序优先匹配", ColumnSpec{Headers: []string{"货号", "单据日期"}, Index: -1}, 3, "", false},
+		{"找不到表头时用固定列号并提示", ColumnSpec{Headers: []string{"配货数量"}, Index: 8}, 8, "未找到表头 配货数量,使用第 9 列作为字段", false},
+		{"没有配置表头时不提示", ColumnSpec{Index: 5}, 5, "", false},
+		{"Index为-1时必须匹配表头", ColumnSpec{Headers: []string{"数量", "配货数量"}, Index: -1}, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, warning, err := tt.spec.resolve("字段", header)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolve = %d,期望报错", index)
+				}
+				if want := "源文件缺少字段列: 找不到表头 数量/配货数量"; err.Error() != want {
+					t.Errorf("错误信息 %q,期望 %q", err, want)
+				}
+				return
+			}
+			if err != nil || index != tt.wantIndex || warning != tt.wantWarning {
+				t.Errorf("resolve = %d, %q, %v,期望 %d, %q", index, warning, err, tt.wantIndex, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestColumnMappingResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping ColumnMapping
+		header  []string
+		want    columnIndexes
+		wantErr bool
+	}{
+		{"列顺序调整后按表头找到", DefaultColumnMapping(), []string{"货号", "配货数量", "客户", "日期"},
+			columnIndexes{date: 3, customer: 2, product: 0, quantity: 1, minLen: 4}, false},
+		{"旧格式没有表头时按固定列号读取", DefaultColumnMapping(), []string{"", "", "", ""},
+			columnIndexes{date: 0, customer: 2, product: 3, quantity: 8, minLen: 9, warnings: []string{
+				"未找到表头 日期/配货日期/单据日期,使用第 1 列作为日期",
+				"未找到表头 客户/客户名称,使用第 3 列作为客户",
+				"未找到表头 货号/款号,使用第 4 列作为货号",
+				"未找到表头 配货数量,使用第 9 列作为配货数量",
+			}}, false},
+		{"必需的列缺失", ColumnMapping{
+			Date:     ColumnSpec{Headers: []string{"日期"}, Index: -1},
+			Customer: ColumnSpec{Headers: []string{"客户"}, Index: -1},
+			Product:  ColumnSpec{Headers: []string{"货号"}, Index: -1},
+			Quantity: ColumnSpec{Headers: []string{"配货数量"}, Index: -1},
+		}, []string{"日期", "客户", "货号", "数量"}, columnIndexes{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.mapping.resolve(tt.header)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolve = %+v,期望报错", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve 报错: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve = %+v,期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package bround
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AnalysisConfig 是一次分析的全部设置,界面和命令行都以 JSON 文件保存
+type AnalysisConfig struct {
+	Sheets []string `json:"sheets"` // 要生成的报表,见 AllSheets
+
+	// 销量:与前7日对比,需要至少14天的数据;关闭后只需7天
+	DailyWeekCompare bool `json:"dailyWeekCompare"`
+
+	// 客户:货号当日总销量达到该值才列出
+	CustomerMinProductSales int `json:"customerMinProductSales"`
+	// 月货号+客户:客户在整个期间的销量达到该值才列出
+	StyleCustomerMinCustomerSales int `json:"styleCustomerMinCustomerSales"`
+	// 月货号+客户:货号最新一天的销量达到该值才列出
+	StyleCustomerMinLatestSales int `json:"styleCustomerMinLatestSales"`
+	// 月货号:货号最新一天的销量达到该值才列出
+	StyleMinLatestSales int `json:"styleMinLatestSales"`
+
+	// 客户流失:此前至少有这么多天配货的客户才算经常配货
+	ChurnMinActiveDays int `json:"churnMinActiveDays"`
+	// 客户流失:经常配货的客户最近这么多天没有配货即视为沉睡
+	ChurnDormantDays int `json:"churnDormantDays"`
+	// 客户流失:最近7天销量比前7天下降超过该百分比即视为下滑
+	ChurnWeeklyDropPercent int `json:"churnWeeklyDropPercent"`
+
+	// 新品新客:最近这么多天内第一次出现的货号和客户
+	NewWithinDays int `json:"newWithinDays"`
+
+	// 趋势:用截至报表日期的这么多天计算趋势斜率,至少 2 天
+	TrendDays int `json:"trendDays"`
+	// 趋势:斜率相对日均销量超过该百分比判定为上升或下降
+	TrendSlopePercent int `json:"trendSlopePercent"`
+
+	// 预测:预测方法,见 ForecastMethods
+	ForecastMethod string `json:"forecastMethod"`
+
+	// 异常:稳健 z 分数的绝对值达到该值记为异常,常用 3.5
+	AnomalyScore float64 `json:"anomalyScore"`
+
+	// ABC 分类:按销量降序累计占比达到 AbcAPercent 之前为 A 类,达到 AbcBPercent 之前为 B 类,其余为 C 类
+	AbcAPercent int `json:"abcAPercent"`
+	AbcBPercent int `json:"abcBPercent"`
+
+	// 图表:销量、月货号和客户排名表中画前多少个货号或客户,0 表示不画图
+	ChartTopN int `json:"chartTopN"`
+	// 图表:放在数据右侧还是下方,见 ChartPlacements
+	ChartPlacement string `json:"chartPlacement"`
+
+	// 输出格式,见 OutputFormats
+	OutputFormat string `json:"outputFormat"`
+
+	Load   LoadOptions  `json:"-"` // 源文件解析方式,列配置单独保存
+	Period ReportPeriod `json:"-"` // 报表日期和统计范围,每次分析单独指定
+}
+
+// DefaultAnalysisConfig 生成全部报表,阈值与最初写死的数值一致
+func DefaultAnalysisConfig() AnalysisConfig {
+	return AnalysisConfig{
+		Sheets:                        append([]string(nil), AllSheets...),
+		DailyWeekCompare:              true,
+		CustomerMinProductSales:       10,
+		StyleCustomerMinCustomerSales: 20,
+		StyleCustomerMinLatestSales:   10,
+		StyleMinLatestSales:           10,
+		ChurnMinActiveDays:            3,
+		ChurnDormantDays:              7,
+		ChurnWeeklyDropPercent:        50,
+		NewWithinDays:                 7,
+		TrendDays:                     14,
+		TrendSlopePercent:             5,
+		ForecastMethod:                ForecastWeekday,
+		AnomalyScore:                  3.5,
+		AbcAPercent:                   80,
+		AbcBPercent:                   95,
+		ChartTopN:                     10,
+		ChartPlacement:                ChartRight,
+		OutputFormat:                  OutputXlsx,
+		Load:                          DefaultLoadOptions(),
+	}
+}
+
+// Validate 检查报表名称和阈值是否有效
+func (c AnalysisConfig) Validate() error {
+	if len(c.Sheets) == 0 {
+		return fmt.Errorf("没有选择任何报表")
+	}
+	for _, sheet := range c.Sheets {
+		if !IsKnownSheet(sheet) {
+			return fmt.Errorf("未知的报表: %s", sheet)
+		}
+	}
+	if err := c.Period.Validate(); err != nil {
+		return err
+	}
+	thresholds := []struct {
+		name  string
+		value int
+	}{
+		{"customerMinProductSales", c.CustomerMinProductSales},
+		{"styleCustomerMinCustomerSales", c.StyleCustomerMinCustomerSales},
+		{"styleCustomerMinLatestSales", c.StyleCustomerMinLatestSales},
+		{"styleMinLatestSales", c.StyleMinLatestSales},
+		{"churnMinActiveDays", c.ChurnMinActiveDays},
+		{"churnWeeklyDropPercent", c.ChurnWeeklyDropPercent},
+		{"trendSlopePercent", c.TrendSlopePercent},
+		{"chartTopN", c.ChartTopN},
+	}
+	for _, t := range thresholds {
+		if t.value < 0 {
+			return fmt.Errorf("阈值 %s 不能为负数: %d", t.name, t.value)
+		}
+	}
+	if c.ChurnDormantDays < 1 {
+		return fmt.Errorf("阈值 churnDormantDays 至少为 1: %d", c.ChurnDormantDays)
+	}
+	if c.NewWithinDays < 1 {
+		return fmt.Errorf("阈值 newWithinDays 至少为 1: %d", c.NewWithinDays)
+	}
+	if c.TrendDays < 2 {
+		return fmt.Errorf("阈值 trendDays 至少为 2: %d", c.TrendDays)
+	}
+	if c.AnomalyScore <= 0 {
+		return fmt.Errorf("阈值 anomalyScore 必须大于 0: %g", c.AnomalyScore)
+	}
+	if c.AbcAPercent <= 0 || c.AbcAPercent >= c.AbcBPercent || c.AbcBPercent > 100 {
+		return fmt.Errorf("ABC 分类阈值应满足 0 < abcAPercent < abcBPercent <= 100: %d, %d", c.AbcAPercent, c.AbcBPercent)
+	}
+	if c.ChartPlacement != ChartRight && c.ChartPlacement != ChartBelow {
+		return fmt.Errorf("未知的图表位置: %s", c.ChartPlacement)
+	}
+	if _, err := NewForecaster(c.ForecastMethod); err != nil {
+		return err
+	}
+	if _, err := NewReportWriter(c.OutputFormat); err != nil {
+		return err
+	}
+	if c.ChurnWeeklyDropPercent > 100 {
+		return fmt.Errorf("阈值 churnWeeklyDropPercent 不能超过 100: %d", c.ChurnWeeklyDropPercent)
+	}
+	return nil
+}
+
+// LoadAnalysisConfig 读取 JSON 格式的分析设置,文件中没有写的字段保持默认值
+func LoadAnalysisConfig(path string) (AnalysisConfig, error) {
+	cfg := DefaultAnalysisConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("读取分析设置失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("解析分析设置 %s 失败: %w", path, err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// SaveAnalysisConfig 把分析设置写成 JSON 文件,目录不存在时自动创建
+func SaveAnalysisConfig(path string, cfg AnalysisConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("保存分析设置失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("保存分析设置失败: %w", err)
+	}
+	return nil
+}
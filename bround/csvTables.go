@@ -0,0 +1,428 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+)
+
+// csvTable 是 CSVWriter 写出的一个文件,Name 为不带扩展名的文件名
+type csvTable struct {
+	Name string
+	reportTable
+}
+
+// csvTables 把各报表的计算结果排成 CSV 表格,列与工作簿中的表头一致。
+// 文件名取报表名(见 AllSheets),报表中的第二块表格等加后缀;合计和小计行不输出,逐日列的表头总是带年份
+func (data *ReportData) csvTables() []csvTable {
+	var tables []csvTable
+	add := func(name string, table reportTable) {
+		tables = append(tables, csvTable{Name: name, reportTable: table})
+	}
+	if o := data.Overview; o != nil {
+		add(SheetOverview, overviewTable(*o))
+		add(SheetOverview+"-top-styles", topStylesTable(o.TopStyles))
+		add(SheetOverview+"-top-customers", topCustomersTable(o.TopCustomers))
+	}
+	if data.Daily != nil {
+		add(SheetDaily, dailyTable(data.Daily))
+	}
+	if data.Customer != nil {
+		add(SheetCustomer, customerTable(data.Customer))
+	}
+	if data.StyleCustomer != nil {
+		add(SheetStyleCustomer, styleCustomerTable(data.StyleCustomer))
+	}
+	if data.Style != nil {
+		add(SheetStyle, styleTable(data.Style))
+	}
+	if data.MonthCompare != nil {
+		add(SheetMonthCompare, monthCompareTable(data.MonthCompare))
+	}
+	if data.CustomerRank != nil {
+		detail, summary := customerRankTables(data.CustomerRank)
+		add(SheetCustomerRank, detail)
+		add(SheetCustomerRank+"-summary", summary)
+	}
+	if data.Churn != nil {
+		add(SheetChurn, churnTable(data.Churn))
+	}
+	if data.Newcomers != nil {
+		add(SheetNewcomers, newcomersTable(data.Newcomers))
+	}
+	if data.Trend != nil {
+		add(SheetTrend, trendTable(data.Trend))
+	}
+	if data.Forecast != nil {
+		add(SheetForecast, forecastTable(data.Forecast, data.ForecastDays))
+	}
+	if data.Weekday != nil {
+		add(SheetWeekday, weekdayTable(data.Weekday))
+	}
+	if data.Anomalies != nil {
+		add(SheetAnomaly, anomalyTable(data.Anomalies))
+	}
+	if data.Abc != nil {
+		summary, detail := abcTables(data.Abc)
+		add(SheetAbc, detail)
+		add(SheetAbc+"-summary", summary)
+	}
+	if h := data.Hourly; h != nil {
+		dates, customers := hourlyTables(*h)
+		add(SheetHourly, dates)
+		add(SheetHourly+"-customers", customers)
+	}
+	if data.Rejected != nil {
+		add("rejected", rejectedTable(data.Rejected))
+	}
+	return tables
+}
+
+// optional 在 ok 为 false 时返回 nil,CSV 中留空
+func optional(value float64, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return value
+}
+
+// salesDates 返回 series 中出现过的所有日期(2006-01-02),按时间先后排列
+func salesDates(series ...map[string]int) []string {
+	seen := make(map[string]bool)
+	var dates []string
+	for _, daily := range series {
+		for date := range daily {
+			if !seen[date] {
+				seen[date] = true
+				dates = append(dates, date)
+			}
+		}
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// dailyValues 依次取出 dates 中每天的销量,没有销量的日子留空
+func dailyValues(daily map[string]int, dates []string) []interface{} {
+	values := make([]interface{}, len(dates))
+	for i, date := range dates {
+		if quantity, exists := daily[date]; exists {
+			values[i] = quantity
+		}
+	}
+	return values
+}
+
+func overviewTable(o Overview) reportTable {
+	var prevWeek interface{}
+	if o.HasPrevWeek() {
+		prevWeek = o.PrevWeeklySales
+	}
+	return reportTable{
+		Columns: []string{"指标", "当日", "7日", "前7日", "周环比"},
+		Rows: [][]interface{}{
+			{"配货总量", o.DailySales, o.WeeklySales, prevWeek, optional(o.WeeklyChange())},
+			{"活跃货号数", o.DailyStyles, o.WeeklyStyles, nil, nil},
+			{"活跃客户数", o.DailyCustomers, o.WeeklyCustomers, nil, nil},
+		},
+	}
+}
+
+func topStylesTable(stats []ProductStat) reportTable {
+	table := reportTable{Columns: []string{"排名", "货号", "7日销量", "当日销量"}}
+	for i, stat := range stats {
+		table.Rows = append(table.Rows, []interface{}{i + 1, stat.ProductID, stat.WeeklySales, stat.DailySales})
+	}
+	return table
+}
+
+func topCustomersTable(stats []CustomerActivity) reportTable {
+	table := reportTable{Columns: []string{"排名", "客户", "7日销量", "当日销量"}}
+	for i, stat := range stats {
+		table.Rows = append(table.Rows, []interface{}{i + 1, stat.Customer, stat.WeeklySales, stat.DailySales})
+	}
+	return table
+}
+
+func dailyTable(stats []ProductStat) reportTable {
+	table := reportTable{Columns: []string{"货号", "当日销量", "前一日销量", "较前一日", "7日销量", "前7日销量", "七日销量对比", "七日变化率"}}
+	for _, stat := range stats {
+		table.Rows = append(table.Rows, []interface{}{
+			stat.ProductID, stat.DailySales, stat.PrevDaySales, stat.DailySales - stat.PrevDaySales,
+			stat.WeeklySales, stat.PrevWeeklySales, stat.WeeklyCompare, optional(stat.WeeklyChange()),
+		})
+	}
+	return table
+}
+
+// customerTable 与工作表一样按货号总销量降序排列
+func customerTable(stats map[string][]ProductCustomerStat) reportTable {
+	totals := make(map[string]int, len(stats))
+	productIDs := make([]string, 0, len(stats))
+	for productID, customers := range stats {
+		for _, stat := range customers {
+			totals[productID] += stat.Quantity
+		}
+		productIDs = append(productIDs, productID)
+	}
+	sort.Slice(productIDs, func(i, j int) bool {
+		a, b := productIDs[i], productIDs[j]
+		if totals[a] != totals[b] {
+			return totals[a] > totals[b]
+		}
+		return a < b
+	})
+
+	table := reportTable{Columns: []string{"货号", "客户", "数量"}}
+	for _, productID := range productIDs {
+		for _, stat := range stats[productID] {
+			table.Rows = append(table.Rows, []interface{}{productID, stat.Customer, stat.Quantity})
+		}
+	}
+	return table
+}
+
+func styleCustomerTable(products []ProductStats) reportTable {
+	var series []map[string]int
+	for _, product := range products {
+		for _, stat := range product.CustomerStats {
+			series = append(series, stat.DailySales)
+		}
+	}
+	dates := salesDates(series...)
+
+	table := reportTable{Columns: append(append([]string{"货号", "客户"}, dates...), totalTitle)}
+	for _, product := range products {
+		for _, stat := range product.CustomerStats {
+			values := append([]interface{}{product.ProductID, stat.Customer}, dailyValues(stat.DailySales, dates)...)
+			table.Rows = append(table.Rows, append(values, stat.TotalSales))
+		}
+	}
+	return table
+}
+
+func styleTable(reports []StyleReport) reportTable {
+	series := make([]map[string]int, len(reports))
+	for i, report := range reports {
+		series[i] = report.DailySales
+	}
+	dates := salesDates(series...)
+
+	table := reportTable{Columns: append(append([]string{"货号"}, dates...), totalTitle)}
+	for _, report := range reports {
+		values := append([]interface{}{report.StyleID}, dailyValues(report.DailySales, dates)...)
+		table.Rows = append(table.Rows, append(values, report.TotalSales))
+	}
+	return table
+}
+
+func monthCompareTable(stats []MonthCompareStat) reportTable {
+	table := reportTable{Columns: []string{"货号", "本月累计", "上月同期", "环比增长", "去年同期", "同比增长"}}
+	for _, stat := range stats {
+		table.Rows = append(table.Rows, []interface{}{
+			stat.ProductID, stat.MonthToDate, stat.PrevMonth, optional(growthRate(stat.MonthToDate, stat.PrevMonth)),
+			stat.LastYear, optional(growthRate(stat.MonthToDate, stat.LastYear)),
+		})
+	}
+	return table
+}
+
+// customerRankTables 返回客户排名明细和帕累托汇总,stats 包含本月全部有销量的客户
+func customerRankTables(stats []CustomerRankStat) (detail, summary reportTable) {
+	monthTotal := 0
+	for _, stat := range stats {
+		monthTotal += stat.MonthlySales
+	}
+	detail = reportTable{Columns: []string{"排名", "客户", "当日销量", "7日销量", "本月销量", "本月占比", "累计占比", "货号数"}}
+	cumulative := 0
+	for i, stat := range stats {
+		cumulative += stat.MonthlySales
+		var share, cumulativeShare interface{}
+		if monthTotal > 0 {
+			share = float64(stat.MonthlySales) / float64(monthTotal)
+			cumulativeShare = float64(cumulative) / float64(monthTotal)
+		}
+		detail.Rows = append(detail.Rows, []interface{}{
+			i + 1, stat.Customer, stat.DailySales, stat.WeeklySales, stat.MonthlySales, share, cumulativeShare, stat.Products,
+		})
+	}
+
+	customers, topCustomers, topShare := paretoSummary(stats, monthTotal)
+	summary = reportTable{
+		Columns: []string{"汇总项", "数值"},
+		Rows: [][]interface{}{
+			{"本月有销量的客户数", customers},
+			{"本月总销量", monthTotal},
+			{fmt.Sprintf("前%.0f%%客户数", paretoShare*100), topCustomers},
+			{fmt.Sprintf("前%.0f%%客户销量占比", paretoShare*100), topShare},
+		},
+	}
+	return detail, summary
+}
+
+func churnTable(stats []ChurnStat) reportTable {
+	table := reportTable{Columns: []string{"客户", "类型", "最后配货日期", "未配货天数", "配货天数", "7日销量", "前7日销量", "七日变化率"}}
+	for _, stat := range stats {
+		kind := "下滑"
+		if stat.Dormant {
+			kind = "沉睡"
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			stat.Customer, kind, stat.LastDate.Format("2006-01-02"), stat.IdleDays, stat.ActiveDays,
+			stat.WeeklySales, stat.PrevWeeklySales, optional(stat.WeeklyChange()),
+		})
+	}
+	return table
+}
+
+func newcomersTable(stats []NewcomerStat) reportTable {
+	series := make([]map[string]int, len(stats))
+	for i, stat := range stats {
+		series[i] = stat.DailySales
+	}
+	dates := salesDates(series...)
+
+	table := reportTable{Columns: append(append([]string{"类型", "名称", "首次出现", "客户/货号数"}, dates...), totalTitle)}
+	for _, stat := range stats {
+		values := append([]interface{}{stat.Kind, stat.Name, stat.FirstDate.Format("2006-01-02"), stat.Partners},
+			dailyValues(stat.DailySales, dates)...)
+		table.Rows = append(table.Rows, append(values, stat.TotalSales))
+	}
+	return table
+}
+
+func trendTable(trends []StyleTrend) reportTable {
+	table := reportTable{Columns: []string{"货号", "趋势期销量", "7日均量", "趋势斜率", "相对斜率", "趋势"}}
+	for _, trend := range trends {
+		table.Rows = append(table.Rows, []interface{}{
+			trend.StyleID, trend.RecentSales, trend.MovingAverage, trend.Slope, trend.RelativeSlope, trend.Class,
+		})
+	}
+	return table
+}
+
+func forecastTable(forecasts []StyleForecast, horizons []int) reportTable {
+	table := reportTable{Columns: []string{"货号", "近7日销量"}}
+	for _, horizon := range horizons {
+		table.Columns = append(table.Columns, fmt.Sprintf("预测%d日", horizon))
+	}
+	table.Columns = append(table.Columns, "回测误差")
+	for _, forecast := range forecasts {
+		values := []interface{}{forecast.StyleID, forecast.RecentSales}
+		for i := range horizons {
+			var total interface{}
+			if i < len(forecast.Totals) {
+				total = forecast.Totals[i]
+			}
+			values = append(values, total)
+		}
+		table.Rows = append(table.Rows, append(values, optional(forecast.Error, forecast.HasError)))
+	}
+	return table
+}
+
+func weekdayTable(stats []WeekdayStat) reportTable {
+	table := reportTable{Columns: []string{"货号"}}
+	for _, weekday := range weekdayOrder {
+		table.Columns = append(table.Columns, weekdayNames[weekday])
+	}
+	table.Columns = append(table.Columns, "报表日期销量", fmt.Sprintf("前%d周同日均量", weekdayCompareWeeks), "同日对比")
+	for _, stat := range stats {
+		values := []interface{}{stat.StyleID}
+		for _, weekday := range weekdayOrder {
+			values = append(values, stat.Averages[weekday])
+		}
+		var sameDay, compare interface{}
+		if stat.HasSameDay {
+			sameDay = stat.SameDayAvg
+			if stat.SameDayAvg > 0 {
+				compare = (float64(stat.AsOfSales) - stat.SameDayAvg) / stat.SameDayAvg
+			}
+		}
+		table.Rows = append(table.Rows, append(values, stat.AsOfSales, sameDay, compare))
+	}
+	return table
+}
+
+func anomalyTable(anomalies []Anomaly) reportTable {
+	table := reportTable{Columns: []string{"类型", "名称", "日期", "销量", "基线中位数", "偏离分数", "方向"}}
+	for _, anomaly := range anomalies {
+		direction := "偏高"
+		if anomaly.Score < 0 {
+			direction = "偏低"
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			anomaly.Kind, anomaly.Name, anomaly.Date.Format("2006-01-02"), anomaly.Quantity, anomaly.Baseline, anomaly.Score, direction,
+		})
+	}
+	return table
+}
+
+// abcTables 返回每类的汇总和每个货号的明细
+func abcTables(classes []StyleClass) (summary, detail reportTable) {
+	counts := make(map[string]int)
+	sales := make(map[string]int)
+	totalSales := 0
+	detail = reportTable{Columns: []string{"货号", "销量", "占比", "累计占比", "类别"}}
+	for _, class := range classes {
+		counts[class.Class]++
+		sales[class.Class] += class.TotalSales
+		totalSales += class.TotalSales
+		detail.Rows = append(detail.Rows, []interface{}{class.StyleID, class.TotalSales, class.Share, class.Cumulative, class.Class})
+	}
+
+	summary = reportTable{Columns: []string{"类别", "货号数", "货号占比", "销量", "销量占比"}}
+	for _, class := range abcClasses {
+		var countShare, salesShare interface{}
+		if len(classes) > 0 {
+			countShare = float64(counts[class]) / float64(len(classes))
+		}
+		if totalSales > 0 {
+			salesShare = float64(sales[class]) / float64(totalSales)
+		}
+		summary.Rows = append(summary.Rows, []interface{}{class, counts[class], countShare, sales[class], salesShare})
+	}
+	return summary, detail
+}
+
+// hourlyTables 返回 日期×小时 和 主要客户×小时 两张表,只包含有销量的小时段
+func hourlyTables(stats HourlyStats) (dates, customers reportTable) {
+	hourColumns := func(first string) []string {
+		columns := []string{first}
+		for hour := stats.MinHour; hour <= stats.MaxHour; hour++ {
+			columns = append(columns, fmt.Sprintf("%02d时", hour))
+		}
+		return append(columns, totalLabel)
+	}
+	hourValues := func(label string, values *[24]int) []interface{} {
+		row := []interface{}{label}
+		total := 0
+		for hour := stats.MinHour; hour <= stats.MaxHour; hour++ {
+			quantity := 0
+			if values != nil {
+				quantity = values[hour]
+			}
+			row = append(row, quantity)
+			total += quantity
+		}
+		return append(row, total)
+	}
+
+	dates = reportTable{Columns: hourColumns("日期")}
+	for _, date := range stats.Dates {
+		dateStr := date.Format("2006-01-02")
+		dates.Rows = append(dates.Rows, hourValues(dateStr, stats.Daily[dateStr]))
+	}
+	customers = reportTable{Columns: hourColumns("客户")}
+	for _, customer := range stats.Customers {
+		customers.Rows = append(customers.Rows, hourValues(customer, stats.Customer[customer]))
+	}
+	return dates, customers
+}
+
+func rejectedTable(rejected []RejectedRow) reportTable {
+	table := reportTable{Columns: []string{"行号", "原因", "原始数据"}}
+	for _, r := range rejected {
+		table.Rows = append(table.Rows, []interface{}{r.Row, r.Reason, r.Data})
+	}
+	return table
+}
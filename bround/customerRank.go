@@ -0,0 +1,176 @@
+package bround
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// paretoShare 是帕累托汇总中 "头部客户" 所占的客户比例
+const paretoShare = 0.2
+
+// CustomerRankStat 是一个客户在报表日期当天、最近7天和本月累计的销量
+type CustomerRankStat struct {
+	Customer     string `json:"customer"`
+	DailySales   int    `json:"dailySales"`
+	WeeklySales  int    `json:"weeklySales"`
+	MonthlySales int    `json:"monthlySales"`
+	Products     int    `json:"products"` // 本月购买过的不同货号数
+}
+
+func getCustomerRank(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]CustomerRankStat, error) {
+	day := dateWindow{from: p.asOf, to: p.asOf}
+	week := dateWindow{from: p.asOf.AddDate(0, 0, -6), to: p.asOf}
+	month, _, _ := monthCompareWindows(p.asOf)
+
+	// 1. 按客户汇总三个区间的销量
+	progress.stage(weightAnalyze)
+	stats, monthTotal := calculateCustomerRank(records, day, week, month, progress)
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  7日:%s  本月:%s", p.dateCaption(), week, month)
+	err := createCustomerRankReport(f, sheetName, stats, monthTotal, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+	if err := addCustomerPieChart(f, sheetName, stats, monthTotal, cfg); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// calculateCustomerRank 返回按本月销量降序排列的客户,以及本月全部客户的总销量
+func calculateCustomerRank(records []SaleRecord, day, week, month dateWindow, progress *progressTracker) ([]CustomerRankStat, int) {
+	statMap := make(map[string]*CustomerRankStat)
+	products := make(map[string]map[string]bool)
+	monthTotal := 0
+
+	for i, record := range records {
+		progress.step(i, len(records), "统计 客户排名:正在分析数据")
+		inDay, inWeek, inMonth := day.contains(record.Date), week.contains(record.Date), month.contains(record.Date)
+		if !inWeek && !inMonth {
+			continue
+		}
+		stat, exists := statMap[record.Customer]
+		if !exists {
+			stat = &CustomerRankStat{Customer: record.Customer}
+			statMap[record.Customer] = stat
+			products[record.Customer] = make(map[string]bool)
+		}
+		if inDay {
+			stat.DailySales += record.Quantity
+		}
+		if inWeek {
+			stat.WeeklySales += record.Quantity
+		}
+		if inMonth {
+			stat.MonthlySales += record.Quantity
+			monthTotal += record.Quantity
+			products[record.Customer][record.ProductID] = true
+		}
+	}
+
+	stats := make([]CustomerRankStat, 0, len(statMap))
+	for customer, stat := range statMap {
+		stat.Products = len(products[customer])
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.MonthlySales != b.MonthlySales {
+			return a.MonthlySales > b.MonthlySales
+		}
+		if a.WeeklySales != b.WeeklySales {
+			return a.WeeklySales > b.WeeklySales
+		}
+		if a.DailySales != b.DailySales {
+			return a.DailySales > b.DailySales
+		}
+		return a.Customer < b.Customer
+	})
+	return stats, monthTotal
+}
+
+// paretoSummary 返回本月有销量的客户中前 paretoShare 的客户数及其销量占比,stats 需已按本月销量降序排列
+func paretoSummary(stats []CustomerRankStat, monthTotal int) (customers, topCustomers int, share float64) {
+	for _, stat := range stats {
+		if stat.MonthlySales > 0 {
+			customers++
+		}
+	}
+	if customers == 0 || monthTotal == 0 {
+		return customers, 0, 0
+	}
+	topCustomers = int(math.Ceil(float64(customers) * paretoShare))
+	topSales := 0
+	for _, stat := range stats[:topCustomers] {
+		topSales += stat.MonthlySales
+	}
+	return customers, topCustomers, float64(topSales) / float64(monthTotal)
+}
+
+func createCustomerRankReport(f *excelize.File, sheetName string, stats []CustomerRankStat, monthTotal int, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	titles := []string{"排名", "客户", "当日销量", "7日销量", "本月销量", "本月占比", "累计占比", "货号数"}
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	cumulative := 0
+	for i, stat := range stats {
+		progress.step(i, len(stats), "统计 客户排名:正在写入数据")
+		row := i + firstRow
+		cumulative += stat.MonthlySales
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), i+1)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), stat.Customer)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), stat.DailySales)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), stat.WeeklySales)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), stat.MonthlySales)
+		if monthTotal > 0 {
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), float64(stat.MonthlySales)/float64(monthTotal))
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), float64(cumulative)/float64(monthTotal))
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), stat.Products)
+	}
+	if len(stats) > 0 {
+		lastRow := firstRow + len(stats) - 1
+		f.SetCellStyle(sheetName, fmt.Sprintf("F%d", firstRow), fmt.Sprintf("G%d", lastRow), percentStyle)
+	}
+
+	// 帕累托汇总写在明细下方,空一行,带表头以便导出 CSV/JSON 时作为独立的表
+	customers, topCustomers, share := paretoSummary(stats, monthTotal)
+	row := firstRow + len(stats) + 1
+	summary := []struct {
+		label string
+		value interface{}
+	}{
+		{"本月有销量的客户数", customers},
+		{"本月总销量", monthTotal},
+		{fmt.Sprintf("前%.0f%%客户数", paretoShare*100), topCustomers},
+		{fmt.Sprintf("前%.0f%%客户销量占比", paretoShare*100), share},
+	}
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "汇总项")
+	f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), "数值")
+	row++
+	for i, item := range summary {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row+i), item.label)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row+i), item.value)
+	}
+	lastSummaryRow := row + len(summary) - 1
+	f.SetCellStyle(sheetName, fmt.Sprintf("B%d", lastSummaryRow), fmt.Sprintf("B%d", lastSummaryRow), percentStyle)
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package bround
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// DefaultDateLayouts 是各系统导出中见过的日期写法,按顺序尝试
+var DefaultDateLayouts = []string{
+	"1/2/06 15:04", // Excel 日期单元格的默认显示格式
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+	"1/2/06",
+	"01-02-06",
+	"2006年1月2日",
+}
+
+// Excel 序列号的有效范围:1900-01-01 到 9999-12-31
+const maxExcelSerial = 2958465
+
+// parseDate 依次尝试 layouts,都不匹配时把纯数字按 Excel 日期序列号解析
+func parseDate(value string, layouts []string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 && serial <= maxExcelSerial {
+		return excelize.ExcelDateToTime(serial, false)
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期 %q", value)
+}
@@ -0,0 +1,47 @@
+package bround
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		layouts []string
+		want    time.Time
+		wantErr bool
+	}{
+		{"Excel默认显示格式", "8/1/24 09:30", DefaultDateLayouts, time.Date(2024, 8, 1, 9, 30, 0, 0, time.UTC), false},
+		{"ISO日期", "2024-08-01", DefaultDateLayouts, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), false},
+		{"斜杠日期去掉首尾空格", " 2024/8/1 ", DefaultDateLayouts, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), false},
+		{"中文日期", "2024年8月1日", DefaultDateLayouts, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), false},
+		{"Excel序列号", "45505", DefaultDateLayouts, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), false},
+		{"带时间的序列号", "45505.5", DefaultDateLayouts, time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC), false},
+		{"没有格式时只认序列号", "45505", nil, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), false},
+		{"不在格式列表中", "2024-08-01", []string{"2006/1/2"}, time.Time{}, true},
+		{"文字", "昨天", DefaultDateLayouts, time.Time{}, true},
+		{"空值", "", DefaultDateLayouts, time.Time{}, true},
+		{"序列号为0", "0", DefaultDateLayouts, time.Time{}, true},
+		{"负数", "-1", DefaultDateLayouts, time.Time{}, true},
+		{"超出Excel日期范围", "3000000", DefaultDateLayouts, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDate(tt.value, tt.layouts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDate(%q) = %v,期望报错", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDate(%q) 报错: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %v,期望 %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package bround
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"path"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetRowCount 从工作表的 <dimension ref="A1:L5000"> 中取出行数,取不到时返回 0。
+//
+// 注意:不要改用 f.GetSheetDimension 之类的 excelize 接口,它们会把整张工作表
+// 解析进内存(30 万行的导出要占 4GB 以上),流式读取就白做了。这里直接打开 xlsx
+// 压缩包,只解码工作表 XML 中 <sheetData> 之前的部分,内存占用与行数无关,
+// TestSheetRowCountDoesNotLoadSheet 会检查这一点。
+func sheetRowCount(filename, sheet string) int {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return 0
+	}
+	defer zr.Close()
+
+	name := worksheetPath(&zr.Reader, sheet)
+	if name == "" {
+		return 0
+	}
+	file, err := zr.Open(name)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "dimension":
+			return dimensionRows(xmlAttr(start, "ref"))
+		case "sheetData": // dimension 只会出现在 sheetData 之前
+			return 0
+		}
+	}
+}
+
+// worksheetPath 通过 workbook.xml 和它的关系文件找到工作表在压缩包中的路径
+func worksheetPath(zr *zip.Reader, sheet string) string {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if decodeZipXML(zr, "xl/workbook.xml", &workbook) != nil || decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels) != nil {
+		return ""
+	}
+
+	var id string
+	for _, s := range workbook.Sheets {
+		if s.Name == sheet {
+			id = s.ID
+			break
+		}
+	}
+	for _, rel := range rels.Relationships {
+		if id == "" || rel.ID != id {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return ""
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v interface{}) error {
+	file, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return xml.NewDecoder(file).Decode(v)
+}
+
+func xmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// dimensionRows 取出尺寸范围的最后一行,如 "A1:L5000" 返回 5000
+func dimensionRows(ref string) int {
+	parts := strings.Split(ref, ":")
+	_, row, err := excelize.CellNameToCoordinates(parts[len(parts)-1])
+	if err != nil {
+		return 0
+	}
+	return row
+}
@@ -0,0 +1,89 @@
+package bround
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeTestWorkbook 生成一个第一张表有 rows 行、4 列的工作簿。
+// excelize 保存时不更新尺寸,这里像 Excel 导出的文件一样写上 A1:D<rows>
+func writeTestWorkbook(t *testing.T, rows int) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	for i := 1; i <= rows; i++ {
+		if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", i), &[]interface{}{"8/1/24 09:30", "A01", "客户", i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.SetSheetDimension(sheet, fmt.Sprintf("A1:D%d", rows)); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), fmt.Sprintf("rows%d.xlsx", rows))
+	if err := f.SaveAs(name); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestSheetRowCount(t *testing.T) {
+	name := writeTestWorkbook(t, 25)
+	tests := []struct {
+		name     string
+		filename string
+		sheet    string
+		want     int
+	}{
+		{"读取尺寸中的行数", name, "Sheet1", 25},
+		{"工作表不存在", name, "没有这张表", 0},
+		{"文件不存在", filepath.Join(t.TempDir(), "missing.xlsx"), "Sheet1", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sheetRowCount(tt.filename, tt.sheet); got != tt.want {
+				t.Errorf("sheetRowCount = %d,期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDimensionRows(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"A1:L5000", 5000},
+		{"A1", 1},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := dimensionRows(tt.ref); got != tt.want {
+			t.Errorf("dimensionRows(%q) = %d,期望 %d", tt.ref, got, tt.want)
+		}
+	}
+}
+
+// 回归测试:取行数不能把整张表读入内存(曾经用 GetSheetDimension,30 万行时占用 4GB 以上)。
+// 行数增加 100 倍,每次调用分配的内存不应随之增长
+func TestSheetRowCountDoesNotLoadSheet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("生成大工作簿较慢")
+	}
+	small, large := writeTestWorkbook(t, 200), writeTestWorkbook(t, 20000)
+	bytesPerCall := func(name string) int64 {
+		return testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				sheetRowCount(name, "Sheet1")
+			}
+		}).AllocedBytesPerOp()
+	}
+	smallBytes, largeBytes := bytesPerCall(small), bytesPerCall(large)
+	if largeBytes > 2*smallBytes {
+		t.Errorf("20000 行分配 %d 字节,200 行分配 %d 字节,取行数时读入了整张表", largeBytes, smallBytes)
+	}
+}
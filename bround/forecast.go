@@ -0,0 +1,209 @@
+package bround
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ForecastSmoothing = "smoothing" // 指数平滑
+	ForecastWeekday   = "weekday"   // 按星期几的季节性平均
+)
+
+// ForecastMethods 列出 AnalysisConfig.ForecastMethod 可选的预测方法
+var ForecastMethods = []string{ForecastSmoothing, ForecastWeekday}
+
+// forecastHorizons 是预测表中汇总的未来天数
+var forecastHorizons = []int{7, 14, 30}
+
+// backtestDays 是回测时留出的最后几天
+const backtestDays = 7
+
+// Forecaster 根据逐日销量预测之后每天的销量
+type Forecaster interface {
+	Name() string
+	// Forecast 中 history 的最后一个值是 lastDate 当天的销量,返回 lastDate 之后 days 天每天的预测值
+	Forecast(history []float64, lastDate time.Time, days int) []float64
+}
+
+// NewForecaster 按名称返回预测方法,名称见 ForecastMethods
+func NewForecaster(method string) (Forecaster, error) {
+	switch method {
+	case ForecastSmoothing:
+		return ExponentialSmoothing{Alpha: 0.3}, nil
+	case ForecastWeekday:
+		return WeekdayAverage{Weeks: 4}, nil
+	}
+	return nil, fmt.Errorf("未知的预测方法: %s", method)
+}
+
+// ExponentialSmoothing 是简单指数平滑,预测值为最后的平滑水平
+type ExponentialSmoothing struct {
+	Alpha float64 // 平滑系数,越大越看重最近的销量
+}
+
+func (ExponentialSmoothing) Name() string { return "指数平滑" }
+
+func (s ExponentialSmoothing) Forecast(history []float64, lastDate time.Time, days int) []float64 {
+	level := 0.0
+	for i, value := range history {
+		if i == 0 {
+			level = value
+			continue
+		}
+		level = s.Alpha*value + (1-s.Alpha)*level
+	}
+	forecast := make([]float64, days)
+	for i := range forecast {
+		forecast[i] = level
+	}
+	return forecast
+}
+
+// WeekdayAverage 用最近 Weeks 周同一个星期几的平均销量作为预测
+type WeekdayAverage struct {
+	Weeks int
+}
+
+func (WeekdayAverage) Name() string { return "星期平均" }
+
+func (w WeekdayAverage) Forecast(history []float64, lastDate time.Time, days int) []float64 {
+	var sums, counts [7]float64
+	// 从最后一天往前数,只看最近 Weeks 周
+	for i := 0; i < len(history) && i < w.Weeks*7; i++ {
+		weekday := lastDate.AddDate(0, 0, -i).Weekday()
+		sums[weekday] += history[len(history)-1-i]
+		counts[weekday]++
+	}
+	fallback := mean(history)
+	forecast := make([]float64, days)
+	for i := range forecast {
+		weekday := lastDate.AddDate(0, 0, i+1).Weekday()
+		if counts[weekday] > 0 {
+			forecast[i] = sums[weekday] / counts[weekday]
+		} else {
+			forecast[i] = fallback
+		}
+	}
+	return forecast
+}
+
+// StyleForecast 是一个货号未来几天的预测销量和回测误差
+type StyleForecast struct {
+	StyleID     string  `json:"styleId"`
+	RecentSales int     `json:"recentSales"` // 最近7天的实际销量
+	Totals      []int   `json:"totals"`      // 与 forecastHorizons 对应的预测总量
+	Error       float64 `json:"error"`
+	HasError    bool    `json:"hasError"` // 历史太短或回测期没有销量时无法回测
+}
+
+// forecastStyle 预测一个货号,并用留出最后 backtestDays 天的方式计算加权绝对百分比误差(WAPE)
+func forecastStyle(styleID string, history []float64, lastDate time.Time, forecaster Forecaster) StyleForecast {
+	result := StyleForecast{StyleID: styleID}
+	for i := len(history) - 1; i >= 0 && i >= len(history)-7; i-- {
+		result.RecentSales += int(history[i])
+	}
+
+	maxHorizon := forecastHorizons[len(forecastHorizons)-1]
+	forecast := forecaster.Forecast(history, lastDate, maxHorizon)
+	for _, horizon := range forecastHorizons {
+		total := 0.0
+		for _, value := range forecast[:horizon] {
+			total += value
+		}
+		result.Totals = append(result.Totals, int(math.Round(total)))
+	}
+
+	// 回测至少需要留出期之前还有两周的历史
+	if len(history) >= backtestDays+14 {
+		train, actual := history[:len(history)-backtestDays], history[len(history)-backtestDays:]
+		predicted := forecaster.Forecast(train, lastDate.AddDate(0, 0, -backtestDays), backtestDays)
+		var absError, actualTotal float64
+		for i := range actual {
+			absError += math.Abs(actual[i] - predicted[i])
+			actualTotal += actual[i]
+		}
+		if actualTotal > 0 {
+			result.Error = absError / actualTotal
+			result.HasError = true
+		}
+	}
+	return result
+}
+
+func getForecastReport(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]StyleForecast, error) {
+	forecaster, err := NewForecaster(cfg.ForecastMethod)
+	if err != nil {
+		return nil, err
+	}
+	// 只用报表日期及之前的数据预测
+	history := dateWindow{from: p.from, to: p.asOf}
+
+	// 1. 复用 月货号 的逐日汇总,逐个货号预测
+	progress.stage(weightAnalyze)
+	styleMap, _ := aggregateStyleSales(records, period{asOf: p.asOf, from: history.from, to: history.to}, "统计 预测:正在分析数据", progress)
+	forecasts := make([]StyleForecast, 0, len(styleMap))
+	for styleID, report := range styleMap {
+		forecasts = append(forecasts, forecastStyle(styleID, dailySeries(report.DailySales, history), p.asOf, forecaster))
+	}
+	sort.Slice(forecasts, func(i, j int) bool {
+		if forecasts[i].Totals[0] != forecasts[j].Totals[0] {
+			return forecasts[i].Totals[0] > forecasts[j].Totals[0]
+		}
+		return forecasts[i].StyleID < forecasts[j].StyleID
+	})
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  方法:%s  历史:%s  误差为留出最后%d天回测的 WAPE", p.dateCaption(), forecaster.Name(), history, backtestDays)
+	err = createForecastReport(f, sheetName, forecasts, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return forecasts, nil
+}
+
+func createForecastReport(f *excelize.File, sheetName string, forecasts []StyleForecast, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	titles := []string{"货号", "近7日销量"}
+	for _, horizon := range forecastHorizons {
+		titles = append(titles, fmt.Sprintf("预测%d日", horizon))
+	}
+	titles = append(titles, "回测误差")
+	errorCol := len(titles)
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	for i, forecast := range forecasts {
+		progress.step(i, len(forecasts), "统计 预测:正在写入数据")
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), forecast.StyleID)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), forecast.RecentSales)
+		for j, total := range forecast.Totals {
+			cell, _ := excelize.CoordinatesToCellName(j+3, row)
+			f.SetCellValue(sheetName, cell, total)
+		}
+		if forecast.HasError {
+			cell, _ := excelize.CoordinatesToCellName(errorCol, row)
+			f.SetCellValue(sheetName, cell, forecast.Error)
+			f.SetCellStyle(sheetName, cell, cell, percentStyle)
+		}
+	}
+
+	return nil
+}
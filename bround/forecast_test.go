@@ -0,0 +1,76 @@
+package bround
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// constantForecaster 每天都预测同一个值,并记下回测时传入的最后日期
+type constantForecaster struct {
+	value     float64
+	lastDates *[]time.Time
+}
+
+func (constantForecaster) Name() string { return "常数" }
+
+func (c constantForecaster) Forecast(history []float64, lastDate time.Time, days int) []float64 {
+	*c.lastDates = append(*c.lastDates, lastDate)
+	forecast := make([]float64, days)
+	for i := range forecast {
+		forecast[i] = c.value
+	}
+	return forecast
+}
+
+func repeat(value float64, days int) []float64 {
+	history := make([]float64, days)
+	for i := range history {
+		history[i] = value
+	}
+	return history
+}
+
+func TestForecastStyle(t *testing.T) {
+	lastDate := time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		history      []float64
+		value        float64
+		wantTotals   []int
+		wantRecent   int
+		wantError    float64
+		wantHasError bool
+	}{
+		{"预测准确", repeat(10, 21), 10, []int{70, 140, 300}, 70, 0, true},
+		{"预测偏低一半", repeat(10, 21), 5, []int{35, 70, 150}, 70, 0.5, true},
+		{"预测偏高", repeat(4, 30), 5, []int{35, 70, 150}, 28, 0.25, true},
+		{"历史不足三周不回测", repeat(10, 20), 10, []int{70, 140, 300}, 70, 0, false},
+		{"回测期没有销量", append(repeat(10, 14), repeat(0, 7)...), 10, []int{70, 140, 300}, 0, 0, false},
+		{"历史不足7天", repeat(3, 2), 3, []int{21, 42, 90}, 6, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lastDates []time.Time
+			got := forecastStyle("A", tt.history, lastDate, constantForecaster{value: tt.value, lastDates: &lastDates})
+			if got.RecentSales != tt.wantRecent || got.HasError != tt.wantHasError || math.Abs(got.Error-tt.wantError) > 1e-9 {
+				t.Errorf("forecastStyle = %+v,期望近7日 %d、回测误差 %v(%v)", got, tt.wantRecent, tt.wantError, tt.wantHasError)
+			}
+			if len(got.Totals) != len(tt.wantTotals) {
+				t.Fatalf("预测总量 %v,期望 %v", got.Totals, tt.wantTotals)
+			}
+			for i := range tt.wantTotals {
+				if got.Totals[i] != tt.wantTotals[i] {
+					t.Errorf("预测总量 %v,期望 %v", got.Totals, tt.wantTotals)
+					break
+				}
+			}
+			// 回测时以留出期前一天为最后日期
+			if len(tt.history) >= backtestDays+14 {
+				if len(lastDates) != 2 || !lastDates[1].Equal(lastDate.AddDate(0, 0, -backtestDays)) {
+					t.Errorf("回测的最后日期 %v,期望 %v", lastDates, lastDate.AddDate(0, 0, -backtestDays))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package bround
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	headerFill  = "D9E1F2" // 表头底色
+	stripeFill  = "F2F2F2" // 隔行底色
+	minColWidth = 9        // 公式单元格读不到值,合计列至少留出这个宽度
+	maxColWidth = 50
+)
+
+// signedColumns 是正数标绿、负数标红的列,按表头匹配
+var signedColumns = map[string]bool{
+	"较前一日":   true,
+	"七日销量对比": true,
+	"七日变化率":  true,
+	"环比增长":   true,
+	"同比增长":   true,
+	"同日对比":   true,
+	"周环比":    true,
+	"趋势斜率":   true,
+}
+
+// sheetFormatter 给报表统一加格式,在已有单元格样式(百分比、异常标红等)的基础上叠加边框和底色
+type sheetFormatter struct {
+	f      *excelize.File
+	styles map[styleKey]int
+}
+
+type styleKey struct {
+	base    int
+	header  bool
+	stripe  bool
+	integer bool // 整数单元格,原样式没有数字格式时加千分位
+	bold    bool // 合计和小计行
+}
+
+func newSheetFormatter(f *excelize.File) *sheetFormatter {
+	return &sheetFormatter{f: f, styles: make(map[styleKey]int)}
+}
+
+// formatReportSheet 给 writeReportHeader 写出的报表加格式:表头加粗、冻结、自动筛选,
+// 数据区加边框和隔行底色,整数加千分位,涨跌列加红绿条件格式,并按内容调整列宽。
+// 第二行为空的工作表不处理。
+func (sf *sheetFormatter) formatReportSheet(sheetName string) error {
+	f := sf.f
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return err
+	}
+	headerRow := reportFirstRow - 1
+	if len(rows) < headerRow || len(rows[headerRow-1]) == 0 {
+		return nil
+	}
+	titles := rows[headerRow-1]
+	lastCol := len(titles)
+
+	// 主表到第一个空行为止,下方的汇总等只调整列宽
+	lastRow := headerRow
+	for lastRow < len(rows) && !isBlankRow(rows[lastRow]) {
+		lastRow++
+	}
+
+	for row := headerRow; row <= lastRow; row++ {
+		for col := 1; col <= lastCol; col++ {
+			cell, _ := excelize.CoordinatesToCellName(col, row)
+			base, err := f.GetCellStyle(sheetName, cell)
+			if err != nil {
+				return err
+			}
+			key := styleKey{base: base, header: row == headerRow, stripe: row > headerRow && (row-reportFirstRow)%2 == 1}
+			if !key.header {
+				key.bold = isSummaryRow(rows[row-1])
+				// 合计、小计行和总计列是求和公式,没有缓存值,读出来是空的
+				key.integer = key.bold || titles[col-1] == totalTitle
+				if col <= len(rows[row-1]) && rows[row-1][col-1] != "" {
+					_, err := strconv.Atoi(rows[row-1][col-1])
+					key.integer = key.integer || err == nil
+				}
+			}
+			style, err := sf.style(key)
+			if err != nil {
+				return err
+			}
+			f.SetCellStyle(sheetName, cell, cell, style)
+		}
+	}
+
+	// 最后的合计行不参与筛选和排序
+	filterRow := lastRow
+	if filterRow > headerRow && isSummaryRow(rows[filterRow-1]) {
+		filterRow--
+	}
+	sortable, err := sortableRows(f, sheetName, rows, headerRow, filterRow)
+	if err != nil {
+		return err
+	}
+	if filterRow > headerRow && sortable {
+		first, _ := excelize.CoordinatesToCellName(1, headerRow)
+		last, _ := excelize.CoordinatesToCellName(lastCol, filterRow)
+		if err := f.AutoFilter(sheetName, first+":"+last, nil); err != nil {
+			return err
+		}
+	}
+	if lastRow > headerRow {
+		if err := sf.signedFormat(sheetName, titles, lastRow); err != nil {
+			return err
+		}
+	}
+	if err := f.SetPanes(sheetName, &excelize.Panes{
+		Freeze: true, XSplit: 1, YSplit: headerRow,
+		TopLeftCell: "B3", ActivePane: "bottomRight",
+	}); err != nil {
+		return err
+	}
+	return sf.autoFit(sheetName, rows)
+}
+
+// sortableRows 判断 fromRow 到 toRow 能否加自动筛选:有合并单元格(如按货号合并)或中间夹着小计行时,
+// 排序会打乱分组和小计公式,不加筛选
+func sortableRows(f *excelize.File, sheetName string, rows [][]string, fromRow, toRow int) (bool, error) {
+	for row := fromRow; row <= toRow; row++ {
+		if isSummaryRow(rows[row-1]) {
+			return false, nil
+		}
+	}
+	merged, err := f.GetMergeCells(sheetName)
+	if err != nil {
+		return false, err
+	}
+	for _, mc := range merged {
+		_, start, _ := excelize.CellNameToCoordinates(mc.GetStartAxis())
+		_, end, _ := excelize.CellNameToCoordinates(mc.GetEndAxis())
+		if start <= toRow && end >= fromRow {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// style 返回在 key.base 样式上叠加边框、表头或隔行底色后的样式,相同组合只创建一次
+func (sf *sheetFormatter) style(key styleKey) (int, error) {
+	if id, ok := sf.styles[key]; ok {
+		return id, nil
+	}
+	style, err := sf.f.GetStyle(key.base)
+	if err != nil {
+		return 0, err
+	}
+	style.Border = []excelize.Border{
+		{Type: "left", Color: "BFBFBF", Style: 1},
+		{Type: "right", Color: "BFBFBF", Style: 1},
+		{Type: "top", Color: "BFBFBF", Style: 1},
+		{Type: "bottom", Color: "BFBFBF", Style: 1},
+	}
+	if key.integer && style.NumFmt == 0 && style.CustomNumFmt == nil {
+		style.NumFmt = 3 // #,##0
+	}
+	// 已有底色(异常等)的单元格保留原底色
+	hasFill := len(style.Fill.Color) > 0
+	switch {
+	case key.header:
+		if style.Font == nil {
+			style.Font = &excelize.Font{}
+		}
+		style.Font.Bold = true
+		style.Fill = excelize.Fill{Type: "pattern", Color: []string{headerFill}, Pattern: 1}
+		style.Alignment = &excelize.Alignment{Horizontal: "center", Vertical: "center"}
+	case key.stripe && !hasFill:
+		style.Fill = excelize.Fill{Type: "pattern", Color: []string{stripeFill}, Pattern: 1}
+	}
+	if key.bold {
+		if style.Font == nil {
+			style.Font = &excelize.Font{}
+		}
+		style.Font.Bold = true
+	}
+	if style.Alignment == nil {
+		style.Alignment = &excelize.Alignment{Vertical: "center"}
+	}
+	id, err := sf.f.NewStyle(style)
+	if err != nil {
+		return 0, err
+	}
+	sf.styles[key] = id
+	return id, nil
+}
+
+// signedFormat 给 signedColumns 中的列加条件格式:正数绿色,负数红色
+func (sf *sheetFormatter) signedFormat(sheetName string, titles []string, lastRow int) error {
+	green, err := sf.f.NewConditionalStyle(&excelize.Style{Font: &excelize.Font{Color: "006100"}})
+	if err != nil {
+		return err
+	}
+	red, err := sf.f.NewConditionalStyle(&excelize.Style{Font: &excelize.Font{Color: "C00000"}})
+	if err != nil {
+		return err
+	}
+	for col, title := range titles {
+		if !signedColumns[title] {
+			continue
+		}
+		first, _ := excelize.CoordinatesToCellName(col+1, reportFirstRow)
+		last, _ := excelize.CoordinatesToCellName(col+1, lastRow)
+		err := sf.f.SetConditionalFormat(sheetName, first+":"+last, []excelize.ConditionalFormatOptions{
+			{Type: "cell", Criteria: ">", Format: green, Value: "0"},
+			{Type: "cell", Criteria: "<", Format: red, Value: "0"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// autoFit 按每列最长内容设置列宽,第一行的说明文字不参与计算
+func (sf *sheetFormatter) autoFit(sheetName string, rows [][]string) error {
+	var widths []int
+	for i, row := range rows {
+		if i == 0 {
+			continue
+		}
+		for col, value := range row {
+			for len(widths) <= col {
+				widths = append(widths, 0)
+			}
+			if w := displayWidth(value); w > widths[col] {
+				widths[col] = w
+			}
+		}
+	}
+	for col, width := range widths {
+		width += 2
+		if width < minColWidth {
+			width = minColWidth
+		}
+		if width > maxColWidth {
+			width = maxColWidth
+		}
+		name, _ := excelize.ColumnNumberToName(col + 1)
+		if err := sf.f.SetColWidth(sheetName, name, name, float64(width)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// displayWidth 估算文字在 Excel 中占的字符宽度,中文等宽字符按 2 计
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if utf8.RuneLen(r) > 1 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,177 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// hourlyTopCustomers 是时段表中单独列出的销量最大的客户数
+const hourlyTopCustomers = 10
+
+// HourlyStats 是统计范围内按 日期 × 小时 和 客户 × 小时 汇总的销量
+type HourlyStats struct {
+	Dates     []time.Time         `json:"dates"`
+	Daily     map[string]*[24]int `json:"daily"`        // 键为 2006-01-02
+	Customers []string            `json:"topCustomers"` // 按期间销量降序的前 hourlyTopCustomers 个客户
+	Customer  map[string]*[24]int `json:"customers"`
+	Overall   [24]int             `json:"overall"`
+	MinHour   int                 `json:"minHour"` // 有销量的最早和最晚小时,表格只写这段
+	MaxHour   int                 `json:"maxHour"`
+	HasTime   bool                `json:"hasTime"` // 源数据的日期列是否带时间,全部为 0 点时视为没有
+}
+
+// calculateHourlyStats 按小时汇总统计范围内的销量,SaleRecord.Date 保留了源文件中的时间
+func calculateHourlyStats(records []SaleRecord, p period, progress *progressTracker) HourlyStats {
+	stats := HourlyStats{
+		Daily:    make(map[string]*[24]int),
+		Customer: make(map[string]*[24]int),
+		MinHour:  23,
+	}
+	customerTotals := make(map[string]int)
+
+	for i, record := range records {
+		progress.step(i, len(records), "统计 时段:正在分析数据")
+		if !p.contains(record.Date) {
+			continue
+		}
+		hour := record.Date.Hour()
+		if hour != 0 || record.Date.Minute() != 0 {
+			stats.HasTime = true
+		}
+		dateStr := record.Date.Format("2006-01-02")
+		if _, exists := stats.Daily[dateStr]; !exists {
+			stats.Daily[dateStr] = &[24]int{}
+		}
+		if _, exists := stats.Customer[record.Customer]; !exists {
+			stats.Customer[record.Customer] = &[24]int{}
+		}
+		stats.Daily[dateStr][hour] += record.Quantity
+		stats.Customer[record.Customer][hour] += record.Quantity
+		stats.Overall[hour] += record.Quantity
+		customerTotals[record.Customer] += record.Quantity
+		if hour < stats.MinHour {
+			stats.MinHour = hour
+		}
+		if hour > stats.MaxHour {
+			stats.MaxHour = hour
+		}
+	}
+	if stats.MinHour > stats.MaxHour {
+		stats.MinHour = stats.MaxHour
+	}
+
+	for date := p.from; !date.After(p.to); date = date.AddDate(0, 0, 1) {
+		stats.Dates = append(stats.Dates, date)
+	}
+	for customer := range customerTotals {
+		stats.Customers = append(stats.Customers, customer)
+	}
+	sort.Slice(stats.Customers, func(i, j int) bool {
+		a, b := stats.Customers[i], stats.Customers[j]
+		if customerTotals[a] != customerTotals[b] {
+			return customerTotals[a] > customerTotals[b]
+		}
+		return a < b
+	})
+	if len(stats.Customers) > hourlyTopCustomers {
+		stats.Customers = stats.Customers[:hourlyTopCustomers]
+	}
+	return stats
+}
+
+func getHourlyReport(f *excelize.File, sheetName string, records []SaleRecord, p period, progress *progressTracker) (HourlyStats, error) {
+	// 1. 按小时汇总
+	progress.stage(weightAnalyze)
+	stats := calculateHourlyStats(records, p, progress)
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := p.rangeCaption() + "  按配货时间的小时汇总,颜色越深销量越大"
+	if !stats.HasTime {
+		caption += "  (源文件日期列没有时间,全部计入 0 点)"
+	}
+	err := createHourlyReport(f, sheetName, stats, caption, progress)
+	if err != nil {
+		return HourlyStats{}, err
+	}
+
+	return stats, nil
+}
+
+func createHourlyReport(f *excelize.File, sheetName string, stats HourlyStats, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	hours := stats.MaxHour - stats.MinHour + 1
+	titles := []string{"日期"}
+	for hour := stats.MinHour; hour <= stats.MaxHour; hour++ {
+		titles = append(titles, fmt.Sprintf("%02d时", hour))
+	}
+	titles = append(titles, "合计")
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	// writeHours 写一行各小时的销量和合计
+	writeHours := func(row int, label string, values *[24]int) {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), label)
+		total := 0
+		for hour := stats.MinHour; hour <= stats.MaxHour; hour++ {
+			cell, _ := excelize.CoordinatesToCellName(hour-stats.MinHour+2, row)
+			if values != nil && values[hour] != 0 {
+				f.SetCellValue(sheetName, cell, values[hour])
+			}
+			if values != nil {
+				total += values[hour]
+			}
+		}
+		totalCell, _ := excelize.CoordinatesToCellName(hours+2, row)
+		f.SetCellValue(sheetName, totalCell, total)
+	}
+	// heatmap 给 fromRow 到 toRow 的小时格子加色阶
+	heatmap := func(fromRow, toRow int) error {
+		if toRow < fromRow {
+			return nil
+		}
+		first, _ := excelize.CoordinatesToCellName(2, fromRow)
+		last, _ := excelize.CoordinatesToCellName(hours+1, toRow)
+		return f.SetConditionalFormat(sheetName, first+":"+last, []excelize.ConditionalFormatOptions{{
+			Type: "2_color_scale", Criteria: "=",
+			MinType: "min", MaxType: "max",
+			MinColor: "#FFFFFF", MaxColor: "#F8696B",
+		}})
+	}
+
+	// 日期 × 小时
+	row := firstRow
+	for i, date := range stats.Dates {
+		progress.step(i, len(stats.Dates), "统计 时段:正在写入数据")
+		writeHours(row, date.Format("2006-01-02"), stats.Daily[date.Format("2006-01-02")])
+		row++
+	}
+	if err := heatmap(firstRow, row-1); err != nil {
+		return err
+	}
+	overall := stats.Overall
+	writeHours(row, "合计", &overall)
+
+	// 主要客户 × 小时,空一行后另起表头
+	row += 2
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "客户")
+	for col, title := range titles[1:] {
+		cell, _ := excelize.CoordinatesToCellName(col+2, row)
+		f.SetCellValue(sheetName, cell, title)
+	}
+	row++
+	customerRow := row
+	for _, customer := range stats.Customers {
+		writeHours(row, customer, stats.Customer[customer])
+		row++
+	}
+	return heatmap(customerRow, row-1)
+}
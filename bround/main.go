@@ -1,60 +1,265 @@
 package bround
 
 import (
-	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	excelize "github.com/xuri/excelize/v2"
 )
 
-type ProgressInfo struct { //传给前端的进度
-	Num  int    `json:"num"`
-	Text string `json:"text"`
+// 可单独生成的报表
+const (
+	SheetOverview      = "overview"       // 总览
+	SheetDaily         = "daily"          // 销量
+	SheetCustomer      = "customer"       // 客户
+	SheetStyleCustomer = "style-customer" // 月货号+客户
+	SheetStyle         = "style"          // 月货号
+	SheetMonthCompare  = "month-compare"  // 月同期对比
+	SheetCustomerRank  = "customer-rank"  // 客户排名
+	SheetChurn         = "churn"          // 客户流失
+	SheetNewcomers     = "new"            // 新品新客
+	SheetTrend         = "trend"          // 趋势
+	SheetForecast      = "forecast"       // 预测
+	SheetWeekday       = "weekday"        // 星期规律
+	SheetAnomaly       = "anomaly"        // 异常
+	SheetAbc           = "abc"            // ABC分类
+	SheetHourly        = "hourly"         // 时段
+)
+
+// defaultSheet 是 excelize.NewFile 自带的工作表
+const defaultSheet = "Sheet1"
+
+// AllSheets 按生成顺序列出全部报表
+var AllSheets = []string{SheetOverview, SheetDaily, SheetCustomer, SheetStyleCustomer, SheetStyle, SheetMonthCompare, SheetCustomerRank, SheetChurn, SheetNewcomers, SheetTrend, SheetForecast, SheetWeekday, SheetAnomaly, SheetAbc, SheetHourly}
+
+// DefaultOutputPath 在输入文件旁生成 "<文件名>_分析完成_<报表日期><ext>",ext 见 ReportWriter.Ext
+func DefaultOutputPath(inputFilePath string, reportDate time.Time, ext string) string {
+	dir := filepath.Dir(inputFilePath)
+	fileName := filepath.Base(inputFilePath)
+	fileExt := filepath.Ext(fileName)
+	fileNameWithoutExt := fileName[:len(fileName)-len(fileExt)]
+	return filepath.Join(dir, fileNameWithoutExt+"_分析完成_"+reportDate.Format("2006-01-02")+ext)
+}
+
+// Summary 汇总一次分析的结果,供界面和命令行展示
+type Summary struct {
+	Records    int           `json:"records"`    // 参与分析的记录数
+	Rejected   []RejectedRow `json:"rejected"`   // 宽松模式下没能解析的行
+	Warnings   []string      `json:"warnings"`   // 读取源文件时的提示,如找不到表头时按固定列号读取
+	ReportDate string        `json:"reportDate"` // 报表日期
+	From       string        `json:"from"`       // 多日报表的起始日期
+	To         string        `json:"to"`         // 多日报表的结束日期
+	OutputPath string        `json:"outputPath"` // 实际写入的文件
 }
 
-func Main_go(inputFilePath string, outFilePath string, ctx context.Context) error {
+// Main_go 按 cfg 生成报表,只生成 cfg.Sheets 中列出的报表,顺序与 AllSheets 一致,按 cfg.OutputFormat 写出。
+// outFilePath 为空时按报表日期写到输入文件旁,见 DefaultOutputPath
+func Main_go(inputFilePath string, outFilePath string, cfg AnalysisConfig, reporter ProgressReporter) (Summary, error) {
+	var summary Summary
+	if err := cfg.Validate(); err != nil {
+		return summary, err
+	}
+	writer, err := NewReportWriter(cfg.OutputFormat)
+	if err != nil {
+		return summary, err
+	}
+	selected := make(map[string]bool)
+	for _, sheet := range cfg.Sheets {
+		selected[sheet] = true
+	}
+
+	progress := newProgressTracker(reporter, weightLoad+len(selected)*weightSheet)
+
+	// 源文件只解析一次,各报表共用
+	progress.stage(weightLoad)
+	records, rejected, warnings, err := loadSalesRecords(inputFilePath, cfg.Load, progress)
+	summary.Warnings = warnings
+	if err != nil {
+		return summary, err
+	}
+	summary.Records = len(records)
+	summary.Rejected = rejected
+	if len(records) == 0 {
+		return summary, fmt.Errorf("源文件中没有有效的配货记录")
+	}
+
+	// 报表日期默认取数据中的最新日期,统计范围默认覆盖全部数据
+	p, err := resolvePeriod(records, cfg.Period)
+	if err != nil {
+		return summary, err
+	}
+	summary.ReportDate = p.asOf.Format("2006-01-02")
+	summary.From = p.from.Format("2006-01-02")
+	summary.To = p.to.Format("2006-01-02")
+	if len(p.recordsIn(records)) == 0 {
+		return summary, fmt.Errorf("%s 至 %s 之间没有配货记录", summary.From, summary.To)
+	}
+	if outFilePath == "" {
+		outFilePath = DefaultOutputPath(inputFilePath, p.asOf, writer.Ext())
+	}
+
+	// 各报表的计算结果,供 JSON 等结构化输出使用
+	data := ReportData{ReportDate: summary.ReportDate, From: summary.From, To: summary.To, Rejected: rejected}
 
 	// 创建新的 Excel 文件
 	f := excelize.NewFile()
 	defer f.Close()
 
-	now := time.Now()
-	// 生成文件名
-	//filename := fmt.Sprintf("销售统计_%s.xlsx", now.Format("2006-01-02"))
-	//outputFilePath := filepath.Join(filepath.Dir(inputFilePath), filename)
-
 	// 调用各个函数，传入 Excel 文件和工作表名
-	sheet1Name := now.Format("01.02") + "销量"
-	err := getOneDaySale(f, sheet1Name, inputFilePath, ctx)
-	if err != nil {
-		fmt.Println("sheet1Name:", err)
-		return err
+	if selected[SheetOverview] {
+		sheet0Name := "总览"
+		overview, err := getOverview(f, sheet0Name, records, p, progress)
+		if err != nil {
+			return summary, err
+		}
+		data.Overview = &overview
 	}
-	sheet2Name := now.Format("01.02") + "客户"
-	err = getCustomerSale(f, sheet2Name, inputFilePath, ctx)
-	if err != nil {
-		fmt.Println("sheet2Name:", err)
-		return err
+	if selected[SheetDaily] {
+		sheet1Name := p.asOf.Format("01.02") + "销量"
+		data.Daily, err = getOneDaySale(f, sheet1Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
 	}
-	sheet3Name := now.Format("01") + "月货号+客户"
-	err = getStyleSale(f, sheet3Name, inputFilePath, ctx)
-	if err != nil {
-		fmt.Println("sheet3Name:", err)
-		return err
+	if selected[SheetCustomer] {
+		sheet2Name := p.asOf.Format("01.02") + "客户"
+		data.Customer, err = getCustomerSale(f, sheet2Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
 	}
-	sheet4Name := now.Format("01") + "月货号"
-	err = CreateStyleReport(f, sheet4Name, inputFilePath, ctx)
-	if err != nil {
-		fmt.Println("sheet4Name:", err)
-		return err
+	if selected[SheetStyleCustomer] {
+		sheet3Name := p.asOf.Format("01") + "月货号+客户"
+		data.StyleCustomer, err = getStyleSale(f, sheet3Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetStyle] {
+		sheet4Name := p.asOf.Format("01") + "月货号"
+		data.Style, err = CreateStyleReport(f, sheet4Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetMonthCompare] {
+		sheet5Name := p.asOf.Format("01") + "月同期对比"
+		data.MonthCompare, err = getMonthCompare(f, sheet5Name, records, p, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetCustomerRank] {
+		sheet6Name := p.asOf.Format("01") + "月客户排名"
+		data.CustomerRank, err = getCustomerRank(f, sheet6Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetChurn] {
+		sheet7Name := p.asOf.Format("01.02") + "客户流失"
+		data.Churn, err = getChurnReport(f, sheet7Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetNewcomers] {
+		sheet8Name := p.asOf.Format("01.02") + "新品新客"
+		data.Newcomers, err = getNewcomers(f, sheet8Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetTrend] {
+		sheet9Name := p.asOf.Format("01.02") + "趋势"
+		data.Trend, err = getTrendReport(f, sheet9Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetForecast] {
+		sheet10Name := p.asOf.Format("01.02") + "预测"
+		data.Forecast, err = getForecastReport(f, sheet10Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+		data.ForecastDays = forecastHorizons
+	}
+	if selected[SheetWeekday] {
+		sheet11Name := p.asOf.Format("01.02") + "星期规律"
+		data.Weekday, err = getWeekdayReport(f, sheet11Name, records, p, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetAnomaly] {
+		sheet12Name := p.asOf.Format("01.02") + "异常"
+		data.Anomalies, err = getAnomalyReport(f, sheet12Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
+	}
+	if selected[SheetAbc] {
+		sheet13Name := p.asOf.Format("01") + "月ABC分类"
+		data.Abc, err = getAbcReport(f, sheet13Name, records, p, cfg, progress)
+		if err != nil {
+			return summary, err
+		}
 	}
-	// 保存文件
-	if err := f.SaveAs(outFilePath); err != nil {
-		fmt.Println("保存 Excel 文件失败:", err)
-		return err
+	if selected[SheetHourly] {
+		sheet14Name := p.asOf.Format("01") + "月时段"
+		hourly, err := getHourlyReport(f, sheet14Name, records, p, progress)
+		if err != nil {
+			return summary, err
+		}
+		data.Hourly = &hourly
+	}
+	// 删除新建文件自带的空白 Sheet1,打开时显示第一张报表
+	if len(f.GetSheetList()) > 1 {
+		if err := f.DeleteSheet(defaultSheet); err != nil {
+			return summary, err
+		}
+	}
+	f.SetActiveSheet(0)
+
+	if len(rejected) > 0 {
+		if err := writeRejectedRows(f, "数据问题", rejected); err != nil {
+			return summary, err
+		}
+	}
+	// 统一格式,未生成内容的默认工作表会被跳过
+	formatter := newSheetFormatter(f)
+	for _, name := range f.GetSheetList() {
+		if err := formatter.formatReportSheet(name); err != nil {
+			return summary, err
+		}
+	}
+	// 按输出格式保存文件
+	if err := writer.Write(f, &data, outFilePath); err != nil {
+		return summary, err
+	}
+	summary.OutputPath = outFilePath
+	progress.finish("分析完成")
+	return summary, nil
+}
+
+// writeReportHeader 第一行写报表日期等说明,第二行写列标题,返回数据的起始行号
+func writeReportHeader(f *excelize.File, sheetName string, caption string, titles []string) int {
+	f.SetCellValue(sheetName, "A1", caption)
+	for i, title := range titles {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 2)
+		f.SetCellValue(sheetName, cell, title)
+	}
+	return reportFirstRow
+}
+
+// IsKnownSheet 判断 sheet 是否为 AllSheets 中的报表
+func IsKnownSheet(sheet string) bool {
+	for _, s := range AllSheets {
+		if s == sheet {
+			return true
+		}
 	}
-	runtime.EventsEmit(ctx, "progress", ProgressInfo{Num: 100, Text: "分析完成"})
-	return nil
+	return false
 }
@@ -0,0 +1,153 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// MonthCompareStat 是一个货号本月累计与上月同期、去年同期的对比
+type MonthCompareStat struct {
+	ProductID   string `json:"productId"`
+	MonthToDate int    `json:"monthToDate"` // 本月1日到报表日期
+	PrevMonth   int    `json:"prevMonth"`   // 上月同样天数
+	LastYear    int    `json:"lastYear"`    // 去年同月同样天数
+}
+
+// dateWindow 是包含首尾两天的日期区间
+type dateWindow struct {
+	from, to time.Time
+}
+
+func (w dateWindow) contains(t time.Time) bool {
+	return !t.Before(w.from) && t.Before(w.to.AddDate(0, 0, 1))
+}
+
+func (w dateWindow) String() string {
+	return w.from.Format("2006-01-02") + " 至 " + w.to.Format("2006-01-02")
+}
+
+// monthWindow 返回 year 年 month 月从1日起的 days 天;该月天数不足时截止到月末
+func monthWindow(year int, month time.Month, days int, loc *time.Location) dateWindow {
+	from := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := from.AddDate(0, 1, -1).Day()
+	if days > lastDay {
+		days = lastDay
+	}
+	return dateWindow{from: from, to: from.AddDate(0, 0, days-1)}
+}
+
+// monthCompareWindows 返回本月累计、上月同期和去年同期三个区间
+func monthCompareWindows(asOf time.Time) (mtd, prevMonth, lastYear dateWindow) {
+	days := asOf.Day()
+	mtd = monthWindow(asOf.Year(), asOf.Month(), days, asOf.Location())
+	// 用本月1日往前推一个月,避免 3月31日 减一个月落到 3月3日
+	prev := mtd.from.AddDate(0, -1, 0)
+	prevMonth = monthWindow(prev.Year(), prev.Month(), days, asOf.Location())
+	lastYear = monthWindow(asOf.Year()-1, asOf.Month(), days, asOf.Location())
+	return mtd, prevMonth, lastYear
+}
+
+func getMonthCompare(f *excelize.File, sheetName string, records []SaleRecord, p period, progress *progressTracker) ([]MonthCompareStat, error) {
+	mtd, prevMonth, lastYear := monthCompareWindows(p.asOf)
+
+	// 1. 按三个区间汇总每个货号的销量
+	progress.stage(weightAnalyze)
+	stats := calculateMonthCompare(records, mtd, prevMonth, lastYear, progress)
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  本月累计:%s  上月同期:%s  去年同期:%s", p.dateCaption(), mtd, prevMonth, lastYear)
+	err := createMonthCompareReport(f, sheetName, stats, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func calculateMonthCompare(records []SaleRecord, mtd, prevMonth, lastYear dateWindow, progress *progressTracker) []MonthCompareStat {
+	statMap := make(map[string]*MonthCompareStat)
+	for i, record := range records {
+		progress.step(i, len(records), "统计 同期对比:正在分析数据")
+		var target *int
+		stat, exists := statMap[record.ProductID]
+		if !exists {
+			stat = &MonthCompareStat{ProductID: record.ProductID}
+		}
+		switch {
+		case mtd.contains(record.Date):
+			target = &stat.MonthToDate
+		case prevMonth.contains(record.Date):
+			target = &stat.PrevMonth
+		case lastYear.contains(record.Date):
+			target = &stat.LastYear
+		default:
+			continue
+		}
+		*target += record.Quantity
+		statMap[record.ProductID] = stat
+	}
+
+	stats := make([]MonthCompareStat, 0, len(statMap))
+	for _, stat := range statMap {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].MonthToDate != stats[j].MonthToDate {
+			return stats[i].MonthToDate > stats[j].MonthToDate
+		}
+		return stats[i].ProductID < stats[j].ProductID
+	})
+	return stats
+}
+
+// growthRate 返回 current 相对 base 的增长率,base 为 0 时 ok 为 false
+func growthRate(current, base int) (rate float64, ok bool) {
+	if base == 0 {
+		return 0, false
+	}
+	return float64(current-base) / float64(base), true
+}
+
+func createMonthCompareReport(f *excelize.File, sheetName string, stats []MonthCompareStat, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	titles := []string{"货号", "本月累计", "上月同期", "环比增长", "去年同期", "同比增长"}
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	for i, stat := range stats {
+		progress.step(i, len(stats), "统计 同期对比:正在写入数据")
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), stat.ProductID)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), stat.MonthToDate)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), stat.PrevMonth)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), stat.LastYear)
+		// 对比期没有销量时增长率没有意义,留空
+		if rate, ok := growthRate(stat.MonthToDate, stat.PrevMonth); ok {
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), rate)
+		}
+		if rate, ok := growthRate(stat.MonthToDate, stat.LastYear); ok {
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), rate)
+		}
+	}
+	if len(stats) > 0 {
+		lastRow := firstRow + len(stats) - 1
+		f.SetCellStyle(sheetName, fmt.Sprintf("D%d", firstRow), fmt.Sprintf("D%d", lastRow), percentStyle)
+		f.SetCellStyle(sheetName, fmt.Sprintf("F%d", firstRow), fmt.Sprintf("F%d", lastRow), percentStyle)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package bround
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonthCompareWindows(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	window := func(from, to time.Time) dateWindow { return dateWindow{from: from, to: to} }
+	tests := []struct {
+		name                     string
+		asOf                     time.Time
+		mtd, prevMonth, lastYear dateWindow
+	}{
+		{"月中", day(2024, 5, 15),
+			window(day(2024, 5, 1), day(2024, 5, 15)), window(day(2024, 4, 1), day(2024, 4, 15)), window(day(2023, 5, 1), day(2023, 5, 15))},
+		{"月末上月较短", day(2024, 5, 31),
+			window(day(2024, 5, 1), day(2024, 5, 31)), window(day(2024, 4, 1), day(2024, 4, 30)), window(day(2023, 5, 1), day(2023, 5, 31))},
+		{"闰年3月31日上月截止到2月29日", day(2024, 3, 31),
+			window(day(2024, 3, 1), day(2024, 3, 31)), window(day(2024, 2, 1), day(2024, 2, 29)), window(day(2023, 3, 1), day(2023, 3, 31))},
+		{"平年3月31日上月截止到2月28日", day(2023, 3, 31),
+			window(day(2023, 3, 1), day(2023, 3, 31)), window(day(2023, 2, 1), day(2023, 2, 28)), window(day(2022, 3, 1), day(2022, 3, 31))},
+		{"闰日去年同期截止到2月28日", day(2024, 2, 29),
+			window(day(2024, 2, 1), day(2024, 2, 29)), window(day(2024, 1, 1), day(2024, 1, 29)), window(day(2023, 2, 1), day(2023, 2, 28))},
+		{"1月上月为去年12月", day(2024, 1, 10),
+			window(day(2024, 1, 1), day(2024, 1, 10)), window(day(2023, 12, 1), day(2023, 12, 10)), window(day(2023, 1, 1), day(2023, 1, 10))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mtd, prevMonth, lastYear := monthCompareWindows(tt.asOf)
+			for _, c := range []struct {
+				label     string
+				got, want dateWindow
+			}{{"本月累计", mtd, tt.mtd}, {"上月同期", prevMonth, tt.prevMonth}, {"去年同期", lastYear, tt.lastYear}} {
+				if !c.got.from.Equal(c.want.from) || !c.got.to.Equal(c.want.to) {
+					t.Errorf("%s = %v,期望 %v", c.label, c.got, c.want)
+				}
+			}
+		})
+	}
+}
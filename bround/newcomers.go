@@ -0,0 +1,156 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// NewcomerStat 是最近才第一次出现的货号或客户,以及它从首次出现起的逐日销量
+type NewcomerStat struct {
+	Kind       string         `json:"kind"` // 新货号 / 新客户
+	Name       string         `json:"name"`
+	FirstDate  time.Time      `json:"firstDate"`
+	DailySales map[string]int `json:"dailySales"`
+	TotalSales int            `json:"totalSales"`
+	Partners   int            `json:"partners"` // 新货号的客户数,或新客户买过的货号数
+}
+
+func getNewcomers(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]NewcomerStat, error) {
+	// 首次出现要看报表日期之前的全部数据,不只是统计范围
+	history := period{asOf: p.asOf, from: startOfDay(findEarliestDate(records)), to: p.asOf}
+	window := newcomerWindow(p.asOf, history.from, cfg.NewWithinDays)
+
+	// 1. 分别找出新货号和新客户,两次汇总平分分析阶段的进度
+	progress.stage(weightAnalyze - 1)
+	styleMap, _ := aggregateStyleSales(records, history, "统计 新货号:正在分析数据", progress)
+	progress.stage(1)
+	salesMap := aggregateStyleCustomerSales(records, history, "统计 新客户:正在分析数据", progress)
+	stats := append(newStyles(styleMap, salesMap, window), newCustomers(salesMap, window)...)
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  首次出现:%s", p.dateCaption(), window)
+	err := createNewcomerReport(f, sheetName, stats, window, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// newcomerWindow 返回截至报表日期的 days 天。数据第一天出现的都算不上 "新",
+// 窗口最早从数据第二天开始
+func newcomerWindow(asOf, firstDay time.Time, days int) dateWindow {
+	window := dateWindow{from: asOf.AddDate(0, 0, -(days - 1)), to: asOf}
+	if !window.from.After(firstDay) {
+		window.from = firstDay.AddDate(0, 0, 1)
+	}
+	return window
+}
+
+// newStyles 从 aggregateStyleSales 的结果中挑出首次销售落在 window 内的货号
+func newStyles(styleMap map[string]*StyleReport, salesMap map[string]map[string]map[string]int, window dateWindow) []NewcomerStat {
+	var stats []NewcomerStat
+	for styleID, report := range styleMap {
+		if !window.contains(report.FirstDate) {
+			continue
+		}
+		stats = append(stats, NewcomerStat{
+			Kind:       "新货号",
+			Name:       styleID,
+			FirstDate:  report.FirstDate,
+			DailySales: report.DailySales,
+			TotalSales: report.TotalSales,
+			Partners:   len(salesMap[styleID]),
+		})
+	}
+	sortNewcomers(stats)
+	return stats
+}
+
+// newCustomers 按客户合并 货号 -> 客户 -> 日期 的销量,挑出第一次配货落在 window 内的客户
+func newCustomers(salesMap map[string]map[string]map[string]int, window dateWindow) []NewcomerStat {
+	customerMap := make(map[string]*NewcomerStat)
+	for _, customers := range salesMap {
+		for customer, dailySales := range customers {
+			stat, exists := customerMap[customer]
+			if !exists {
+				stat = &NewcomerStat{Kind: "新客户", Name: customer, DailySales: make(map[string]int)}
+				customerMap[customer] = stat
+			}
+			stat.Partners++
+			for dateStr, quantity := range dailySales {
+				stat.DailySales[dateStr] += quantity
+				stat.TotalSales += quantity
+				date, _ := time.ParseInLocation("2006-01-02", dateStr, window.from.Location())
+				if stat.FirstDate.IsZero() || date.Before(stat.FirstDate) {
+					stat.FirstDate = date
+				}
+			}
+		}
+	}
+
+	var stats []NewcomerStat
+	for _, stat := range customerMap {
+		if window.contains(stat.FirstDate) {
+			stats = append(stats, *stat)
+		}
+	}
+	sortNewcomers(stats)
+	return stats
+}
+
+// sortNewcomers 最早出现的在前,同一天出现的按销量降序
+func sortNewcomers(stats []NewcomerStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].FirstDate.Equal(stats[j].FirstDate) {
+			return stats[i].FirstDate.Before(stats[j].FirstDate)
+		}
+		if stats[i].TotalSales != stats[j].TotalSales {
+			return stats[i].TotalSales > stats[j].TotalSales
+		}
+		return stats[i].Name < stats[j].Name
+	})
+}
+
+func createNewcomerReport(f *excelize.File, sheetName string, stats []NewcomerStat, window dateWindow, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	// 日期列从 window 起到报表日期,首次出现之前的日期留空
+	titles := []string{"类型", "名称", "首次出现", "客户/货号数"}
+	var dates []time.Time
+	for date := window.from; !date.After(window.to); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+		titles = append(titles, dateTitle(date, window.from, window.to))
+	}
+	titles = append(titles, totalTitle)
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	for i, stat := range stats {
+		progress.step(i, len(stats), "统计 新货号和新客户:正在写入数据")
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), stat.Kind)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), stat.Name)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), stat.FirstDate.Format("2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), stat.Partners)
+		for col, date := range dates {
+			if date.Before(stat.FirstDate) {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(col+5, row)
+			f.SetCellValue(sheetName, cell, stat.DailySales[date.Format("2006-01-02")])
+		}
+		totalCell, _ := excelize.CoordinatesToCellName(len(titles), row)
+		f.SetCellValue(sheetName, totalCell, stat.TotalSales)
+	}
+
+	return nil
+}
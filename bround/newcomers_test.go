@@ -0,0 +1,72 @@
+package bround
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewcomerWindow(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		firstDay time.Time
+		days     int
+		want     dateWindow
+	}{
+		{"数据足够长", day(1), 7, dateWindow{from: day(25), to: day(31)}},
+		{"窗口起点正好是数据第一天时后移一天", day(25), 7, dateWindow{from: day(26), to: day(31)}},
+		{"窗口早于数据第一天时从第二天开始", day(28), 7, dateWindow{from: day(29), to: day(31)}},
+		{"窗口起点在数据第一天之后不变", day(24), 7, dateWindow{from: day(25), to: day(31)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newcomerWindow(day(31), tt.firstDay, tt.days); got != tt.want {
+				t.Errorf("newcomerWindow = %v,期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStylesAndCustomers(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	window := dateWindow{from: day(25), to: day(31)}
+	// 货号 -> 客户 -> 日期 -> 销量
+	salesMap := map[string]map[string]map[string]int{
+		"老货号": {"老客户": {"2024-08-01": 5, "2024-08-30": 1}, "新客户甲": {"2024-08-25": 2}},
+		"新货号": {"老客户": {"2024-08-25": 3}, "新客户甲": {"2024-08-26": 4}, "新客户乙": {"2024-08-31": 1}},
+		"窗口前": {"老客户": {"2024-08-24": 1}},
+	}
+	styleMap := map[string]*StyleReport{
+		"老货号": {StyleID: "老货号", FirstDate: day(1), TotalSales: 8},
+		"新货号": {StyleID: "新货号", FirstDate: day(25), TotalSales: 8},
+		"窗口前": {StyleID: "窗口前", FirstDate: day(24), TotalSales: 1},
+	}
+
+	tests := []struct {
+		name string
+		got  []NewcomerStat
+		want []NewcomerStat
+	}{
+		{"新货号", newStyles(styleMap, salesMap, window), []NewcomerStat{
+			{Kind: "新货号", Name: "新货号", FirstDate: day(25), TotalSales: 8, Partners: 3},
+		}},
+		{"新客户按首次出现排序", newCustomers(salesMap, window), []NewcomerStat{
+			{Kind: "新客户", Name: "新客户甲", FirstDate: day(25), TotalSales: 6, Partners: 2},
+			{Kind: "新客户", Name: "新客户乙", FirstDate: day(31), TotalSales: 1, Partners: 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.got) != len(tt.want) {
+				t.Fatalf("得到 %+v,期望 %+v", tt.got, tt.want)
+			}
+			for i, want := range tt.want {
+				got := tt.got[i]
+				if got.Kind != want.Kind || got.Name != want.Name || !got.FirstDate.Equal(want.FirstDate) ||
+					got.TotalSales != want.TotalSales || got.Partners != want.Partners {
+					t.Errorf("第 %d 个 = %+v,期望 %+v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
@@ -1,124 +1,60 @@
 package bround
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
-	"strconv"
 	"time"
 
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"github.com/xuri/excelize/v2"
 )
 
-type SaleRecord struct {
-	Date      time.Time
-	ProductID string
-	Quantity  int
-}
+// ErrInsufficientData 表示数据覆盖的天数不足以完成统计
+var ErrInsufficientData = errors.New("数据不足")
 
 type ProductStat struct {
-	ProductID     string
-	DailySales    int
-	WeeklySales   int
-	WeeklyCompare int
+	ProductID       string `json:"productId"`
+	DailySales      int    `json:"dailySales"`
+	PrevDaySales    int    `json:"prevDaySales"`    // 前一日销量
+	WeeklySales     int    `json:"weeklySales"`     // 最近7日(含当日)销量
+	PrevWeeklySales int    `json:"prevWeeklySales"` // 再往前7日的销量
+	WeeklyCompare   int    `json:"weeklyCompare"`   // 最近7日比前7日增加的销量
 }
 
-func getOneDaySale(f *excelize.File, sheetName string, inputFilePath string, ctx context.Context) error {
-	runtime.EventsEmit(ctx, "progress", ProgressInfo{Num: 10, Text: "统计日销量:开始读取文件"})
-	// 1. 读取 Excel 文件
-	records, err := readExcelFile(inputFilePath)
-	if err != nil {
-		//fmt.Println("Error reading Excel file:", err)
-		return err
+// WeeklyChange 返回最近7日相对前7日的变化率,前7日没有销量时 ok 为 false
+func (s ProductStat) WeeklyChange() (change float64, ok bool) {
+	if s.PrevWeeklySales == 0 {
+		return 0, false
 	}
-	// 2. 找出最近的日期
-	latestDate := findLatestDate(records)
+	return float64(s.WeeklyCompare) / float64(s.PrevWeeklySales), true
+}
 
-	// 3. 计算统计信息
-	runtime.EventsEmit(ctx, "progress", ProgressInfo{Num: 20, Text: "统计日销量:正在分析数据"})
-	stats, err := calculateStats(records, latestDate)
+func getOneDaySale(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]ProductStat, error) {
+	// 1. 以报表日期为当日计算统计信息
+	progress.stage(weightAnalyze)
+	stats, err := calculateStats(records, p.asOf, cfg.DailyWeekCompare, "统计日销量:正在分析数据", progress)
 	if err != nil {
 		//fmt.Println("Error calculating statistics:", err)
-		return err
+		return nil, err
 	}
-	// 4. 生成新的 Excel 文件
-	err = generateExcelReport(f, sheetName, stats)
+	// 2. 生成新的 Excel 文件
+	progress.stage(weightWrite)
+	classes := styleClassMap(records, p, cfg)
+	err = generateExcelReport(f, sheetName, stats, cfg.DailyWeekCompare, classes, p.dateCaption(), progress)
 	if err != nil {
 		//fmt.Println("Error generating Excel report:", err)
-		return err
+		return nil, err
 	}
-	runtime.EventsEmit(ctx, "progress", ProgressInfo{Num: 25, Text: "统计日销量:正在分析数据"})
-	//runtime.EventsEmit(ctx, "progress", "统计日销量:写入数据表完毕")
-	fmt.Println("Sales statistics report generated successfully.")
-	return nil
-}
-
-func readExcelFile(filename string) ([]SaleRecord, error) {
-	f, err := excelize.OpenFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error opening file: %w", err)
-	}
-	defer f.Close()
-
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("no sheets found in the Excel file")
-	}
-
-	rows, err := f.GetRows(sheets[0])
-	if err != nil {
-		return nil, fmt.Errorf("error reading rows: %w", err)
-	}
-
-	var records []SaleRecord
-	for i, row := range rows {
-		if i == 0 { // Skip header row
-			continue
-		}
-		if len(row) < 12 {
-			continue // Skip rows with insufficient data
-		}
-
-		date, err := time.Parse("1/2/06 15:04", row[0])
-		if err != nil {
-			return nil, fmt.Errorf("error parsing date in row %d: %w", i+1, err)
-		}
-
-		quantity, err := strconv.Atoi(row[8]) // 配货数量 is in the 9th column (index 8)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing quantity in row %d: %w", i+1, err)
-		}
-
-		records = append(records, SaleRecord{
-			Date:      date,
-			ProductID: row[3], // 货号 is in the 4th column (index 3)
-			Quantity:  quantity,
-		})
-	}
-
-	return records, nil
-}
-
-func findLatestDate(records []SaleRecord) time.Time {
-	var latestDate time.Time
-	for _, record := range records {
-		if record.Date.After(latestDate) {
-			latestDate = record.Date
-		}
+	if err := addTopBarChart(f, sheetName, len(stats), cfg); err != nil {
+		return nil, err
 	}
-	fmt.Println("最新日期:", latestDate.Format("2006-01-02"))
-	return latestDate
+	//runtime.EventsEmit(ctx, "progress", "统计日销量:写入数据表完毕")
+	return stats, nil
 }
-func calculateStats(records []SaleRecord, latestDate time.Time) ([]ProductStat, error) {
-	if len(records) == 0 {
-		return nil, fmt.Errorf("没有提供记录")
-	}
-
-	// 将最新日期调整为当天的结束时间
-	latestDate = time.Date(latestDate.Year(), latestDate.Month(), latestDate.Day(), 23, 59, 59, 0, latestDate.Location())
 
+// dataRange 返回数据中的最早日期,以及从最早日期到 latestDate 的天数(不完整的第一天按一天计)
+func dataRange(records []SaleRecord, latestDate time.Time) (time.Time, float64) {
 	// 找到实际的最早日期
 	earliestActualDate := records[0].Date
 	for _, record := range records {
@@ -131,21 +67,35 @@ func calculateStats(records []SaleRecord, latestDate time.Time) ([]ProductStat,
 	daysDifference := latestDate.Sub(earliestActualDate).Hours() / 24
 
 	// 向上取整，确保包括不完整的第一天
-	daysDifference = math.Ceil(daysDifference)
+	return earliestActualDate, math.Ceil(daysDifference)
+}
 
-	if daysDifference < 7 {
-		return nil, fmt.Errorf("数据不足:需要至少7天的数据,实际数据范围为 %v 到 %v(%.0f天)",
-			earliestActualDate.Format("2006-01-02"), latestDate.Format("2006-01-02"), daysDifference)
+// calculateStats 统计每个货号在 latestDate 当天、前一日和最近7日的销量;
+// weekCompare 为 true 时还要与再往前的7日对比,因此至少需要14天的数据;text 是分析时显示的进度文字
+func calculateStats(records []SaleRecord, latestDate time.Time, weekCompare bool, text string, progress *progressTracker) ([]ProductStat, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("没有提供记录")
 	}
 
-	fmt.Printf("数据范围：从 %v 到 %v(%.0f天)\n",
-		earliestActualDate.Format("2006-01-02"), latestDate.Format("2006-01-02"), daysDifference)
+	// 将最新日期调整为当天的结束时间
+	latestDate = time.Date(latestDate.Year(), latestDate.Month(), latestDate.Day(), 23, 59, 59, 0, latestDate.Location())
+	earliestActualDate, daysDifference := dataRange(records, latestDate)
+
+	requiredDays := 7
+	if weekCompare {
+		requiredDays = 14
+	}
+	if daysDifference < float64(requiredDays) {
+		return nil, fmt.Errorf("%w:需要至少%d天的数据,实际数据范围为 %v 到 %v(%.0f天)",
+			ErrInsufficientData, requiredDays, earliestActualDate.Format("2006-01-02"), latestDate.Format("2006-01-02"), daysDifference)
+	}
 
 	// Create a map to store sales data for each product
 	salesMap := make(map[string]map[string]int)
 
 	// Populate the salesMap
-	for _, record := range records {
+	for i, record := range records {
+		progress.step(i, len(records), text)
 		// Normalize the record date to the start of the day
 		normalizedDate := time.Date(record.Date.Year(), record.Date.Month(), record.Date.Day(), 0, 0, 0, 0, record.Date.Location())
 		dateStr := normalizedDate.Format("2006-01-02")
@@ -160,30 +110,32 @@ func calculateStats(records []SaleRecord, latestDate time.Time) ([]ProductStat,
 	for productID, sales := range salesMap {
 		latestDateStr := latestDate.Format("2006-01-02")
 		dailySales := sales[latestDateStr]
+		prevDaySales := sales[latestDate.AddDate(0, 0, -1).Format("2006-01-02")]
 		currentWeekSales := 0
 		previousWeekSales := 0
 
-		for i := 0; i < 8; i++ {
+		// 第 0-6 天是最近7日,第 7-13 天是前7日
+		for i := 0; i < 14; i++ {
 			date := latestDate.AddDate(0, 0, -i)
 			dateStr := date.Format("2006-01-02")
 			if i < 7 {
 				currentWeekSales += sales[dateStr]
-			}
-			if i > 0 && i <= 7 {
+			} else {
 				previousWeekSales += sales[dateStr]
 			}
 		}
 
-		weeklyCompare := currentWeekSales - previousWeekSales
-		//这里判断，如果dailySales  currentWeekSales  weeklyCompare 都为0，则直接跳过
-		if dailySales == 0 && currentWeekSales == 0 && weeklyCompare == 0 {
+		//这里判断，如果两周内都没有销量，则直接跳过
+		if currentWeekSales == 0 && previousWeekSales == 0 {
 			continue
 		}
 		stats = append(stats, ProductStat{
-			ProductID:     productID,
-			DailySales:    dailySales,
-			WeeklySales:   currentWeekSales,
-			WeeklyCompare: weeklyCompare,
+			ProductID:       productID,
+			DailySales:      dailySales,
+			PrevDaySales:    prevDaySales,
+			WeeklySales:     currentWeekSales,
+			PrevWeeklySales: previousWeekSales,
+			WeeklyCompare:   currentWeekSales - previousWeekSales,
 		})
 	}
 
@@ -195,7 +147,8 @@ func calculateStats(records []SaleRecord, latestDate time.Time) ([]ProductStat,
 	return stats, nil
 }
 
-func generateExcelReport(f *excelize.File, sheetName string, salesStats []ProductStat) error {
+// generateExcelReport 在最后一列写出货号的 ABC 类别,classes 的键为货号
+func generateExcelReport(f *excelize.File, sheetName string, salesStats []ProductStat, weekCompare bool, classes map[string]string, caption string, progress *progressTracker) error {
 	// 创建新的工作表
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
@@ -204,19 +157,40 @@ func generateExcelReport(f *excelize.File, sheetName string, salesStats []Produc
 	f.SetActiveSheet(index)
 
 	// 设置标题
-	titles := []string{"货号", "当日销量", "7日销量", "七日销量对比"}
-	for i, title := range titles {
-		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-		f.SetCellValue(sheetName, cell, title)
+	titles := []string{"货号", "当日销量", "前一日销量", "较前一日", "7日销量"}
+	if weekCompare {
+		titles = append(titles, "前7日销量", "七日销量对比", "七日变化率")
+	}
+	titles = append(titles, "ABC")
+	classCol := len(titles)
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
 	}
 
 	// 写入数据
 	for i, sales := range salesStats {
-		row := i + 2
+		progress.step(i, len(salesStats), "统计日销量:正在写入数据")
+		row := i + firstRow
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), sales.ProductID)
 		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), sales.DailySales)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), sales.WeeklySales)
-		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), sales.WeeklyCompare)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), sales.PrevDaySales)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), sales.DailySales-sales.PrevDaySales)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), sales.WeeklySales)
+		classCell, _ := excelize.CoordinatesToCellName(classCol, row)
+		f.SetCellValue(sheetName, classCell, classes[sales.ProductID])
+		if !weekCompare {
+			continue
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), sales.PrevWeeklySales)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), sales.WeeklyCompare)
+		// 前7日没有销量时变化率没有意义,留空
+		if change, ok := sales.WeeklyChange(); ok {
+			f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), change)
+			f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), percentStyle)
+		}
 	}
 
 	return nil
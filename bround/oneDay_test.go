@@ -0,0 +1,65 @@
+package bround
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCalculateStats(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2024, 8, d, hour, 0, 0, 0, time.UTC) }
+	latest := day(14, 0)
+	// 报表日期 8月14日:最近7日为 8/08-8/14,前7日为 8/01-8/07
+	records := []SaleRecord{
+		{Date: day(1, 9), ProductID: "A", Quantity: 2},
+		{Date: day(7, 9), ProductID: "A", Quantity: 3},
+		{Date: day(8, 9), ProductID: "A", Quantity: 4},
+		{Date: day(13, 9), ProductID: "A", Quantity: 5},
+		{Date: day(14, 9), ProductID: "A", Quantity: 6},
+		{Date: day(14, 18), ProductID: "A", Quantity: 1},
+		{Date: day(3, 9), ProductID: "B", Quantity: 8},
+		{Date: day(14, 10), ProductID: "C", Quantity: 1},
+		{Date: time.Date(2024, 7, 31, 9, 0, 0, 0, time.UTC), ProductID: "D", Quantity: 9}, // 两个7日之外
+	}
+	tests := []struct {
+		name        string
+		records     []SaleRecord
+		weekCompare bool
+		want        map[string]ProductStat
+		wantErr     bool
+	}{
+		{"最近7日和前7日", records, true, map[string]ProductStat{
+			"A": {ProductID: "A", DailySales: 7, PrevDaySales: 5, WeeklySales: 16, PrevWeeklySales: 5, WeeklyCompare: 11},
+			"B": {ProductID: "B", PrevWeeklySales: 8, WeeklyCompare: -8},
+			"C": {ProductID: "C", DailySales: 1, WeeklySales: 1, WeeklyCompare: 1},
+		}, false},
+		{"对比前7日时不足14天", records[2:8], true, nil, true},
+		{"不对比前7日只需7天", records[2:8], false, map[string]ProductStat{
+			"A": {ProductID: "A", DailySales: 7, PrevDaySales: 5, WeeklySales: 16, WeeklyCompare: 16},
+			"B": {ProductID: "B", PrevWeeklySales: 8, WeeklyCompare: -8},
+			"C": {ProductID: "C", DailySales: 1, WeeklySales: 1, WeeklyCompare: 1},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := calculateStats(tt.records, latest, tt.weekCompare, "", newProgressTracker(nil, 1))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInsufficientData) {
+					t.Fatalf("calculateStats 返回 %v,期望 ErrInsufficientData", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("calculateStats 报错: %v", err)
+			}
+			if len(stats) != len(tt.want) {
+				t.Fatalf("得到 %d 个货号 %+v,期望 %d 个", len(stats), stats, len(tt.want))
+			}
+			for _, stat := range stats {
+				if stat != tt.want[stat.ProductID] {
+					t.Errorf("货号 %s = %+v,期望 %+v", stat.ProductID, stat, tt.want[stat.ProductID])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package bround
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	OutputXlsx = "xlsx" // Excel 工作簿,带格式、图表和公式
+	OutputCSV  = "csv"  // 每张表一个 CSV 文件,UTF-8 带 BOM,Excel 可以直接打开
+	OutputJSON = "json" // 全部报表写到一个 JSON 文件
+)
+
+// OutputFormats 列出 AnalysisConfig.OutputFormat 可选的输出格式
+var OutputFormats = []string{OutputXlsx, OutputCSV, OutputJSON}
+
+// utf8BOM 写在 CSV 开头,否则 Excel 会按本地编码打开导致中文乱码
+const utf8BOM = "\xEF\xBB\xBF"
+
+// ReportWriter 把生成好的工作簿或各报表的计算结果按某种格式写到 outPath
+type ReportWriter interface {
+	// Ext 返回输出文件的扩展名;为空表示输出为目录
+	Ext() string
+	Write(f *excelize.File, data *ReportData, outPath string) error
+}
+
+// ReportData 是各报表的计算结果,字段名固定,日期为 ISO 格式(逐日销量的键为 2006-01-02)。
+// 没有生成或没有数据的报表省略
+type ReportData struct {
+	ReportDate    string                           `json:"reportDate"`
+	From          string                           `json:"from"`
+	To            string                           `json:"to"`
+	Overview      *Overview                        `json:"overview,omitempty"`
+	Daily         []ProductStat                    `json:"daily,omitempty"`
+	Customer      map[string][]ProductCustomerStat `json:"customer,omitempty"` // 键为货号
+	StyleCustomer []ProductStats                   `json:"styleCustomer,omitempty"`
+	Style         []StyleReport                    `json:"style,omitempty"`
+	MonthCompare  []MonthCompareStat               `json:"monthCompare,omitempty"`
+	CustomerRank  []CustomerRankStat               `json:"customerRank,omitempty"`
+	Churn         []ChurnStat                      `json:"churn,omitempty"`
+	Newcomers     []NewcomerStat                   `json:"newcomers,omitempty"`
+	Trend         []StyleTrend                     `json:"trend,omitempty"`
+	Forecast      []StyleForecast                  `json:"forecast,omitempty"`
+	ForecastDays  []int                            `json:"forecastDays,omitempty"` // forecast 中 totals 依次为未来这些天的预测总量
+	Weekday       []WeekdayStat                    `json:"weekday,omitempty"`      // 第一行为全部货号
+	Anomalies     []Anomaly                        `json:"anomalies,omitempty"`
+	Abc           []StyleClass                     `json:"abc,omitempty"`
+	Hourly        *HourlyStats                     `json:"hourly,omitempty"`
+	Rejected      []RejectedRow                    `json:"rejected,omitempty"`
+}
+
+// NewReportWriter 按名称返回输出格式,名称见 OutputFormats
+func NewReportWriter(format string) (ReportWriter, error) {
+	switch format {
+	case OutputXlsx:
+		return XlsxWriter{}, nil
+	case OutputCSV:
+		return CSVWriter{}, nil
+	case OutputJSON:
+		return JSONWriter{}, nil
+	}
+	return nil, fmt.Errorf("未知的输出格式: %s", format)
+}
+
+// XlsxWriter 原样保存工作簿
+type XlsxWriter struct{}
+
+func (XlsxWriter) Ext() string { return ".xlsx" }
+
+func (XlsxWriter) Write(f *excelize.File, data *ReportData, outPath string) error {
+	return f.SaveAs(outPath)
+}
+
+// CSVWriter 在 outPath 目录下为每张报表写一个 "<报表名>.csv"(报表名见 AllSheets),内容取自各报表的计算结果,
+// 报表中的汇总等另写为 "<报表名>-summary.csv" 等,见 ReportData.csvTables。
+// 写出的文件名记在目录下的清单 csvManifest 中,下次写入时只删除清单里的文件
+type CSVWriter struct{}
+
+// csvManifest 列出 CSVWriter 在目录中写出的文件,每行一个文件名
+const csvManifest = ".excelanalyzer-csv"
+
+func (CSVWriter) Ext() string { return "" }
+
+func (CSVWriter) Write(f *excelize.File, data *ReportData, outPath string) error {
+	if err := os.MkdirAll(outPath, 0o755); err != nil {
+		return err
+	}
+	// 删掉上次生成的 CSV,避免残留这次没有选的报表
+	if err := RemoveCSVReport(outPath); err != nil {
+		return err
+	}
+	var written []string
+	for _, table := range data.csvTables() {
+		name := table.Name + ".csv"
+		if err := writeCSV(filepath.Join(outPath, name), table.reportTable); err != nil {
+			return err
+		}
+		written = append(written, name)
+	}
+	return os.WriteFile(filepath.Join(outPath, csvManifest), []byte(strings.Join(written, "\n")+"\n"), 0o644)
+}
+
+// RemoveCSVReport 删除 dir 中上次由 CSVWriter 写出的文件。只删除清单中列出的文件,
+// 目录中的其他文件即使也是 CSV 也不会动;没有清单时什么都不做
+func RemoveCSVReport(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, csvManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, name := range strings.Split(string(data), "\n") {
+		// 清单可能被改过,只接受本目录下的 CSV 文件名
+		if name == "" || name != filepath.Base(name) || filepath.Ext(name) != ".csv" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Remove(filepath.Join(dir, csvManifest))
+}
+
+func writeCSV(path string, table reportTable) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(utf8BOM); err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	w.Write(table.Columns)
+	for _, values := range table.Rows {
+		w.Write(csvRecord(values))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// JSONWriter 把 ReportData 写到一个 JSON 文件,不经过工作簿的显示格式
+type JSONWriter struct{}
+
+func (JSONWriter) Ext() string { return ".json" }
+
+func (JSONWriter) Write(f *excelize.File, data *ReportData, outPath string) error {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, out, 0o644)
+}
+
+// reportTable 是一张 CSV 表格,第一行为列标题。
+// Rows 中的值保留原类型:货号等文本为 string("00015" 不会变成数字),数字为 int 或 float64(百分比为小数),留空为 nil
+type reportTable struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// csvRecord 把一行值转成 CSV 的字段
+func csvRecord(values []interface{}) []string {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			record[i] = v
+		case int:
+			record[i] = strconv.Itoa(v)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
@@ -0,0 +1,167 @@
+package bround
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCSVWriterOnlyRemovesItsOwnFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "my_ledger.csv"), []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次写出两张表,第二次只写一张,上次的另一张应被删除,用户自己的文件保留
+	daily := []ProductStat{{ProductID: "00015", DailySales: 3}}
+	churn := []ChurnStat{{Customer: "甲"}}
+	if err := (CSVWriter{}).Write(nil, &ReportData{Daily: daily, Churn: churn}, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := (CSVWriter{}).Write(nil, &ReportData{Daily: daily}, dir); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{csvManifest, "daily.csv", "my_ledger.csv"}
+	if got := listDir(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("目录中的文件 %v,期望 %v", got, want)
+	}
+
+	// 清单中不是本目录 CSV 的条目不处理
+	manifest := "daily.csv\n../outside.csv\nmy_ledger.txt\n"
+	if err := os.WriteFile(filepath.Join(dir, csvManifest), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveCSVReport(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := listDir(t, dir); !reflect.DeepEqual(got, []string{"my_ledger.csv"}) {
+		t.Errorf("RemoveCSVReport 之后目录中的文件 %v,期望只剩 my_ledger.csv", got)
+	}
+	// 没有清单时什么都不删
+	if err := RemoveCSVReport(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := listDir(t, dir); !reflect.DeepEqual(got, []string{"my_ledger.csv"}) {
+		t.Errorf("没有清单时目录中的文件 %v,期望不变", got)
+	}
+}
+
+func TestJSONWriterUsesReportFields(t *testing.T) {
+	data := &ReportData{
+		ReportDate: "2025-01-02",
+		Style: []StyleReport{{
+			StyleID:    "00015",
+			DailySales: map[string]int{"2024-12-31": 2, "2025-01-02": 3},
+			TotalSales: 5,
+			FirstDate:  time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	path := filepath.Join(t.TempDir(), "out.json")
+	if err := (JSONWriter{}).Write(nil, data, path); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	// 没有生成的报表省略,货号保持文本,跨年的日期带年份
+	want := map[string]interface{}{
+		"reportDate": "2025-01-02",
+		"from":       "",
+		"to":         "",
+		"style": []interface{}{map[string]interface{}{
+			"styleId":    "00015",
+			"dailySales": map[string]interface{}{"2024-12-31": 2.0, "2025-01-02": 3.0},
+			"totalSales": 5.0,
+			"firstDate":  "2024-12-31T00:00:00Z",
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON 输出 %s,期望 %v", raw, want)
+	}
+}
+
+func TestCSVRecord(t *testing.T) {
+	got := csvRecord([]interface{}{"00015", 12, 0.125, nil, true})
+	want := []string{"00015", "12", "0.125", "", "true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("csvRecord = %q,期望 %q", got, want)
+	}
+}
+
+func TestCSVTables(t *testing.T) {
+	data := &ReportData{
+		Overview: &Overview{
+			DailySales: 3, WeeklySales: 10, PrevWeeklySales: 8, Days: 14,
+			TopStyles:    []ProductStat{{ProductID: "00015", WeeklySales: 10, DailySales: 3}},
+			TopCustomers: []CustomerActivity{{Customer: "甲", WeeklySales: 6, DailySales: 2}, {Customer: "乙", WeeklySales: 4, DailySales: 1}},
+		},
+		StyleCustomer: []ProductStats{{ProductID: "00015", CustomerStats: []StyleCustomerStat{
+			{ProductID: "00015", Customer: "甲", DailySales: map[string]int{"2024-12-31": 2, "2025-12-31": 1}, TotalSales: 3},
+			{ProductID: "00015", Customer: "乙", DailySales: map[string]int{"2025-12-31": 4}, TotalSales: 4},
+		}}},
+	}
+	got := make(map[string]reportTable)
+	var names []string
+	for _, table := range data.csvTables() {
+		got[table.Name] = table.reportTable
+		names = append(names, table.Name)
+	}
+	wantNames := []string{"overview", "overview-top-styles", "overview-top-customers", "style-customer"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("文件 %v,期望 %v", names, wantNames)
+	}
+
+	tests := []struct {
+		name string
+		want reportTable
+	}{
+		{"overview", reportTable{Columns: []string{"指标", "当日", "7日", "前7日", "周环比"}, Rows: [][]interface{}{
+			{"配货总量", 3, 10, 8, 0.25},
+			{"活跃货号数", 0, 0, nil, nil},
+			{"活跃客户数", 0, 0, nil, nil},
+		}}},
+		// 前几名货号和客户分成两张表
+		{"overview-top-styles", reportTable{Columns: []string{"排名", "货号", "7日销量", "当日销量"}, Rows: [][]interface{}{
+			{1, "00015", 10, 3},
+		}}},
+		{"overview-top-customers", reportTable{Columns: []string{"排名", "客户", "7日销量", "当日销量"}, Rows: [][]interface{}{
+			{1, "甲", 6, 2},
+			{2, "乙", 4, 1},
+		}}},
+		// 日期列带年份,相隔一年的同月同日不会重名;总计取计算结果
+		{"style-customer", reportTable{Columns: []string{"货号", "客户", "2024-12-31", "2025-12-31", "总计"}, Rows: [][]interface{}{
+			{"00015", "甲", 2, 1, 3},
+			{"00015", "乙", nil, 4, 4},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(got[tt.name], tt.want) {
+				t.Errorf("得到 %#v,期望 %#v", got[tt.name], tt.want)
+			}
+		})
+	}
+}
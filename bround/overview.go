@@ -0,0 +1,221 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	overviewTopN        = 10 // 总览中列出的货号和客户数
+	overviewCompareDays = 14 // 数据不足这么多天时,前7日不完整,不写前7日和周环比
+)
+
+// Overview 是总览表的关键指标,当日为报表日期,7日为截至报表日期的最近7天
+type Overview struct {
+	DailySales      int                `json:"dailySales"`
+	WeeklySales     int                `json:"weeklySales"`
+	PrevWeeklySales int                `json:"prevWeeklySales"` // 再往前7日的配货总量
+	DailyStyles     int                `json:"dailyStyles"`     // 当日有配货的货号数
+	WeeklyStyles    int                `json:"weeklyStyles"`
+	DailyCustomers  int                `json:"dailyCustomers"` // 当日有配货的客户数
+	WeeklyCustomers int                `json:"weeklyCustomers"`
+	Earliest        time.Time          `json:"earliest"` // calculateStats 检测到的数据范围
+	Days            int                `json:"days"`
+	TopStyles       []ProductStat      `json:"topStyles"`    // 按7日销量降序
+	TopCustomers    []CustomerActivity `json:"topCustomers"` // 按7日销量降序
+}
+
+// CustomerActivity 是一个客户在报表日期当天和最近7天的销量
+type CustomerActivity struct {
+	Customer    string `json:"customer"`
+	DailySales  int    `json:"dailySales"`
+	WeeklySales int    `json:"weeklySales"`
+}
+
+// HasPrevWeek 判断数据是否完整覆盖前7日
+func (o Overview) HasPrevWeek() bool {
+	return o.Days >= overviewCompareDays
+}
+
+// WeeklyChange 返回最近7日相对前7日的变化率,前7日数据不完整或没有配货时 ok 为 false
+func (o Overview) WeeklyChange() (change float64, ok bool) {
+	if !o.HasPrevWeek() || o.PrevWeeklySales == 0 {
+		return 0, false
+	}
+	return float64(o.WeeklySales-o.PrevWeeklySales) / float64(o.PrevWeeklySales), true
+}
+
+func getOverview(f *excelize.File, sheetName string, records []SaleRecord, p period, progress *progressTracker) (Overview, error) {
+	// 1. 汇总关键指标,货号和客户两次汇总平分分析阶段的进度
+	progress.stage(weightAnalyze - 1)
+	overview, err := calculateOverview(records, p, progress)
+	if err != nil {
+		return Overview{}, err
+	}
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  数据范围:%s 至 %s(%d天)", p.dateCaption(),
+		overview.Earliest.Format("2006-01-02"), p.asOf.Format("2006-01-02"), overview.Days)
+	if !overview.HasPrevWeek() {
+		caption += fmt.Sprintf("  数据不足%d天,不计算前7日和周环比", overviewCompareDays)
+	}
+	err = createOverviewReport(f, sheetName, overview, caption)
+	if err != nil {
+		return Overview{}, err
+	}
+
+	return overview, nil
+}
+
+// calculateOverview 用 calculateStats 和 customerActivity 的结果汇总总览指标
+func calculateOverview(records []SaleRecord, p period, progress *progressTracker) (Overview, error) {
+	var overview Overview
+	stats, err := calculateStats(records, p.asOf, false, "统计 总览:正在分析货号", progress)
+	if err != nil {
+		return overview, err
+	}
+	latestDate := time.Date(p.asOf.Year(), p.asOf.Month(), p.asOf.Day(), 23, 59, 59, 0, p.asOf.Location())
+	earliest, days := dataRange(records, latestDate)
+	overview.Earliest, overview.Days = earliest, int(days)
+
+	for _, stat := range stats {
+		overview.DailySales += stat.DailySales
+		overview.WeeklySales += stat.WeeklySales
+		overview.PrevWeeklySales += stat.PrevWeeklySales
+		if stat.DailySales > 0 {
+			overview.DailyStyles++
+		}
+		if stat.WeeklySales > 0 {
+			overview.WeeklyStyles++
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].WeeklySales != stats[j].WeeklySales {
+			return stats[i].WeeklySales > stats[j].WeeklySales
+		}
+		return stats[i].ProductID < stats[j].ProductID
+	})
+	overview.TopStyles = stats[:chartRows(len(stats), overviewTopN)]
+
+	progress.stage(1)
+	customers := customerActivity(records, p.asOf, progress)
+	for _, stat := range customers {
+		if stat.DailySales > 0 {
+			overview.DailyCustomers++
+		}
+		if stat.WeeklySales > 0 {
+			overview.WeeklyCustomers++
+		}
+	}
+	sort.SliceStable(customers, func(i, j int) bool {
+		if customers[i].WeeklySales != customers[j].WeeklySales {
+			return customers[i].WeeklySales > customers[j].WeeklySales
+		}
+		return customers[i].Customer < customers[j].Customer
+	})
+	overview.TopCustomers = customers[:chartRows(len(customers), overviewTopN)]
+	return overview, nil
+}
+
+// customerActivity 按客户汇总 asOf 当天和截至 asOf 最近7天的销量,只包含最近7天有配货的客户
+func customerActivity(records []SaleRecord, asOf time.Time, progress *progressTracker) []CustomerActivity {
+	day := dateWindow{from: asOf, to: asOf}
+	week := dateWindow{from: asOf.AddDate(0, 0, -6), to: asOf}
+	activity := make(map[string]*CustomerActivity)
+	for i, record := range records {
+		progress.step(i, len(records), "统计 总览:正在分析客户")
+		if !week.contains(record.Date) {
+			continue
+		}
+		stat, exists := activity[record.Customer]
+		if !exists {
+			stat = &CustomerActivity{Customer: record.Customer}
+			activity[record.Customer] = stat
+		}
+		stat.WeeklySales += record.Quantity
+		if day.contains(record.Date) {
+			stat.DailySales += record.Quantity
+		}
+	}
+	customers := make([]CustomerActivity, 0, len(activity))
+	for _, stat := range activity {
+		customers = append(customers, *stat)
+	}
+	return customers
+}
+
+func createOverviewReport(f *excelize.File, sheetName string, overview Overview, caption string) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	// 关键指标
+	firstRow := writeReportHeader(f, sheetName, caption, []string{"指标", "当日", "7日", "前7日", "周环比"})
+	kpis := []struct {
+		label       string
+		day, week   int
+		prevWeek    interface{}
+		weeklyRatio bool
+	}{
+		{"配货总量", overview.DailySales, overview.WeeklySales, nil, true},
+		{"活跃货号数", overview.DailyStyles, overview.WeeklyStyles, nil, false},
+		{"活跃客户数", overview.DailyCustomers, overview.WeeklyCustomers, nil, false},
+	}
+	if overview.HasPrevWeek() {
+		kpis[0].prevWeek = overview.PrevWeeklySales
+	}
+	for i, kpi := range kpis {
+		row := firstRow + i
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), kpi.label)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), kpi.day)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), kpi.week)
+		if kpi.prevWeek != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), kpi.prevWeek)
+		}
+		if !kpi.weeklyRatio {
+			continue
+		}
+		if change, ok := overview.WeeklyChange(); ok {
+			cell := fmt.Sprintf("E%d", row)
+			f.SetCellValue(sheetName, cell, change)
+			f.SetCellStyle(sheetName, cell, cell, percentStyle)
+		}
+	}
+
+	// 前 N 货号和客户并排写在指标下方,空一行
+	row := firstRow + len(kpis) + 1
+	for col, title := range []string{"排名", "货号", "7日销量", "当日销量", "", "客户", "7日销量", "当日销量"} {
+		cell, _ := excelize.CoordinatesToCellName(col+1, row)
+		f.SetCellValue(sheetName, cell, title)
+	}
+	for i := 0; i < len(overview.TopStyles) || i < len(overview.TopCustomers); i++ {
+		row := row + 1 + i
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), i+1)
+		if i < len(overview.TopStyles) {
+			stat := overview.TopStyles[i]
+			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), stat.ProductID)
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), stat.WeeklySales)
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), stat.DailySales)
+		}
+		if i < len(overview.TopCustomers) {
+			stat := overview.TopCustomers[i]
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), stat.Customer)
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), stat.WeeklySales)
+			f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), stat.DailySales)
+		}
+	}
+
+	return nil
+}
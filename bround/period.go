@@ -0,0 +1,139 @@
+package bround
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReportPeriod 指定分析的日期,格式为 2006-01-02,留空的字段按数据自动确定
+type ReportPeriod struct {
+	AsOf string `json:"asOf"` // 报表日期,日销量和客户报表统计这一天;为空时取 To,再为空取数据中的最新日期
+	From string `json:"from"` // 月货号等多日报表的起始日期,为空时取数据中的最早日期
+	To   string `json:"to"`   // 多日报表的结束日期,为空时取报表日期
+}
+
+// Validate 检查日期格式和先后顺序
+func (p ReportPeriod) Validate() error {
+	_, err := p.parse()
+	return err
+}
+
+type parsedPeriod struct {
+	asOf, from, to time.Time // 零值表示未指定
+}
+
+func (p ReportPeriod) parse() (parsedPeriod, error) {
+	var parsed parsedPeriod
+	fields := []struct {
+		name  string
+		value string
+		dst   *time.Time
+	}{
+		{"报表日期", p.AsOf, &parsed.asOf},
+		{"起始日期", p.From, &parsed.from},
+		{"结束日期", p.To, &parsed.to},
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", field.value)
+		if err != nil {
+			return parsed, fmt.Errorf("%s %q 格式错误,应为 2006-01-02", field.name, field.value)
+		}
+		*field.dst = date
+	}
+	if !parsed.from.IsZero() && !parsed.to.IsZero() && parsed.from.After(parsed.to) {
+		return parsed, fmt.Errorf("起始日期 %s 晚于结束日期 %s", p.From, p.To)
+	}
+	if !parsed.asOf.IsZero() && !parsed.to.IsZero() && parsed.asOf.After(parsed.to) {
+		return parsed, fmt.Errorf("报表日期 %s 晚于结束日期 %s", p.AsOf, p.To)
+	}
+	if !parsed.asOf.IsZero() && !parsed.from.IsZero() && parsed.asOf.Before(parsed.from) {
+		return parsed, fmt.Errorf("报表日期 %s 早于起始日期 %s", p.AsOf, p.From)
+	}
+	return parsed, nil
+}
+
+// period 是按数据补全后的报表日期和统计范围,都是当天零点,范围包含首尾两天
+type period struct {
+	asOf, from, to time.Time
+}
+
+// resolvePeriod 用数据中的最早/最新日期补全未指定的字段
+func resolvePeriod(records []SaleRecord, p ReportPeriod) (period, error) {
+	parsed, err := p.parse()
+	if err != nil {
+		return period{}, err
+	}
+	resolved := period{asOf: parsed.asOf, from: parsed.from, to: parsed.to}
+	if resolved.asOf.IsZero() {
+		if !resolved.to.IsZero() {
+			resolved.asOf = resolved.to
+		} else {
+			resolved.asOf = startOfDay(findLatestDate(records))
+		}
+	}
+	if resolved.to.IsZero() {
+		resolved.to = resolved.asOf
+	}
+	if resolved.from.IsZero() {
+		resolved.from = startOfDay(findEarliestDate(records))
+		if resolved.from.After(resolved.to) {
+			resolved.from = resolved.to
+		}
+	}
+	if resolved.from.After(resolved.to) {
+		// 只指定了起始日期,但数据在那之前就结束了
+		return period{}, fmt.Errorf("起始日期 %s 晚于数据中的最新日期 %s", p.From, resolved.to.Format("2006-01-02"))
+	}
+	return resolved, nil
+}
+
+// contains 判断 t 是否落在统计范围内
+func (p period) contains(t time.Time) bool {
+	return !t.Before(p.from) && t.Before(p.to.AddDate(0, 0, 1))
+}
+
+// recordsIn 只保留统计范围内的记录
+func (p period) recordsIn(records []SaleRecord) []SaleRecord {
+	var kept []SaleRecord
+	for _, record := range records {
+		if p.contains(record.Date) {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// dateCaption 是只统计报表日期当天的工作表的首行说明
+func (p period) dateCaption() string {
+	return "报表日期:" + p.asOf.Format("2006-01-02")
+}
+
+// rangeCaption 是多日报表的首行说明
+func (p period) rangeCaption() string {
+	return fmt.Sprintf("%s  统计范围:%s 至 %s", p.dateCaption(), p.from.Format("2006-01-02"), p.to.Format("2006-01-02"))
+}
+
+// dateTitle 是逐日列的表头,统计范围跨年时带上年份,否则同月同日的两列会重名
+func dateTitle(date, from, to time.Time) string {
+	if from.Year() != to.Year() {
+		return date.Format("2006-01-02")
+	}
+	return date.Format("01/02")
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func findEarliestDate(records []SaleRecord) time.Time {
+	var earliestDate time.Time
+	for _, record := range records {
+		if earliestDate.IsZero() || record.Date.Before(earliestDate) {
+			earliestDate = record.Date
+		}
+	}
+	return earliestDate
+}
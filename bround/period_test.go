@@ -0,0 +1,71 @@
+package bround
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolvePeriod(t *testing.T) {
+	date := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	// 数据范围 8月3日 09:30 至 8月20日 18:00
+	records := []SaleRecord{
+		{Date: time.Date(2024, 8, 20, 18, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2024, 8, 3, 9, 30, 0, 0, time.UTC)},
+		{Date: time.Date(2024, 8, 10, 12, 0, 0, 0, time.UTC)},
+	}
+	tests := []struct {
+		name    string
+		period  ReportPeriod
+		want    period
+		wantErr bool
+	}{
+		{"全部留空按数据补全", ReportPeriod{}, period{asOf: date(8, 20), from: date(8, 3), to: date(8, 20)}, false},
+		{"报表日期为空时取结束日期", ReportPeriod{To: "2024-08-15"}, period{asOf: date(8, 15), from: date(8, 3), to: date(8, 15)}, false},
+		{"结束日期为空时取报表日期", ReportPeriod{AsOf: "2024-08-12"}, period{asOf: date(8, 12), from: date(8, 3), to: date(8, 12)}, false},
+		{"全部指定", ReportPeriod{AsOf: "2024-08-10", From: "2024-08-05", To: "2024-08-18"}, period{asOf: date(8, 10), from: date(8, 5), to: date(8, 18)}, false},
+		{"起始日期不早于结束日期", ReportPeriod{To: "2024-08-01"}, period{asOf: date(8, 1), from: date(8, 1), to: date(8, 1)}, false},
+		{"起始日期晚于数据的最新日期", ReportPeriod{From: "2024-09-01"}, period{}, true},
+		{"起始日期晚于结束日期", ReportPeriod{From: "2024-08-10", To: "2024-08-05"}, period{}, true},
+		{"报表日期晚于结束日期", ReportPeriod{AsOf: "2024-08-10", To: "2024-08-05"}, period{}, true},
+		{"日期格式错误", ReportPeriod{AsOf: "2024/08/10"}, period{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePeriod(records, tt.period)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolvePeriod(%+v) = %+v,期望报错", tt.period, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolvePeriod(%+v) 报错: %v", tt.period, err)
+			}
+			if got != tt.want {
+				t.Errorf("resolvePeriod(%+v) = %+v,期望 %+v", tt.period, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateTitle(t *testing.T) {
+	date := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     string
+	}{
+		{"同一年只写月日", date(2024, 8, 1), date(2024, 8, 31), "08/15"},
+		{"跨年带年份", date(2023, 12, 1), date(2025, 1, 3), "2024-08-15"},
+		{"跨年但不足一年也带年份", date(2024, 8, 1), date(2025, 1, 3), "2024-08-15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dateTitle(date(2024, 8, 15), tt.from, tt.to); got != tt.want {
+				t.Errorf("dateTitle = %q,期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package bround
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type ProgressInfo struct { //传给前端的进度
+	Num  int    `json:"num"`
+	Text string `json:"text"`
+}
+
+// ProgressReporter 接收分析过程中的进度
+type ProgressReporter interface {
+	Report(info ProgressInfo)
+}
+
+// WailsReporter 通过 Wails 事件把进度发给前端
+type WailsReporter struct {
+	ctx context.Context
+}
+
+func NewWailsReporter(ctx context.Context) *WailsReporter {
+	return &WailsReporter{ctx: ctx}
+}
+
+func (r *WailsReporter) Report(info ProgressInfo) {
+	runtime.EventsEmit(r.ctx, "progress", info)
+}
+
+// TerminalReporter 在终端同一行刷新进度条
+type TerminalReporter struct {
+	w io.Writer
+}
+
+func NewTerminalReporter(w io.Writer) *TerminalReporter {
+	return &TerminalReporter{w: w}
+}
+
+func (r *TerminalReporter) Report(info ProgressInfo) {
+	const width = 30
+	filled := info.Num * width / 100
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	// \033[K 清除上一次输出残留的字符
+	fmt.Fprintf(r.w, "\r[%s] %3d%% %s\033[K", bar, info.Num, info.Text)
+	if info.Num >= 100 {
+		fmt.Fprintln(r.w)
+	}
+}
+
+// LogReporter 把进度逐条写入日志,适合 cron 等非交互环境
+type LogReporter struct {
+	logger *log.Logger
+}
+
+// NewLogReporter 创建日志进度输出,logger 为 nil 时使用 log.Default()
+func NewLogReporter(logger *log.Logger) *LogReporter {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogReporter{logger: logger}
+}
+
+func (r *LogReporter) Report(info ProgressInfo) {
+	r.logger.Printf("[%3d%%] %s", info.Num, info.Text)
+}
+
+// NopReporter 丢弃所有进度
+type NopReporter struct{}
+
+func (NopReporter) Report(ProgressInfo) {}
+
+// 各阶段的权重:读取源文件一次,每张报表各自分析和写入
+const (
+	weightLoad    = 10
+	weightAnalyze = 3
+	weightWrite   = 2
+	weightSheet   = weightAnalyze + weightWrite
+)
+
+// progressTracker 按各阶段实际处理的行数折算整体百分比
+type progressTracker struct {
+	reporter ProgressReporter
+	total    int // 全部阶段的权重之和
+	offset   int // 已完成阶段的权重之和
+	weight   int // 当前阶段的权重
+	last     ProgressInfo
+}
+
+func newProgressTracker(reporter ProgressReporter, total int) *progressTracker {
+	if reporter == nil {
+		reporter = NopReporter{}
+	}
+	return &progressTracker{reporter: reporter, total: total, last: ProgressInfo{Num: -1}}
+}
+
+// stage 结束当前阶段并开始一个权重为 weight 的新阶段
+func (t *progressTracker) stage(weight int) {
+	t.offset += t.weight
+	t.weight = weight
+}
+
+// step 上报当前阶段已处理 done/count 行,百分比或文字变化时才通知 reporter
+func (t *progressTracker) step(done, count int, text string) {
+	units := float64(t.offset + t.weight)
+	if count > 0 && done < count {
+		units = float64(t.offset) + float64(t.weight)*float64(done)/float64(count)
+	}
+	num := 0
+	if t.total > 0 {
+		num = int(units * 100 / float64(t.total))
+	}
+	// 100% 留给 finish
+	if num > 99 {
+		num = 99
+	}
+	info := ProgressInfo{Num: num, Text: text}
+	if info == t.last {
+		return
+	}
+	t.last = info
+	t.reporter.Report(info)
+}
+
+func (t *progressTracker) finish(text string) {
+	t.last = ProgressInfo{Num: 100, Text: text}
+	t.reporter.Report(t.last)
+}
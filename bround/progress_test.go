@@ -0,0 +1,63 @@
+package bround
+
+import "testing"
+
+// recordReporter 记录收到的每次进度
+type recordReporter struct {
+	infos []ProgressInfo
+}
+
+func (r *recordReporter) Report(info ProgressInfo) {
+	r.infos = append(r.infos, info)
+}
+
+func TestProgressTrackerStep(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int
+		stages      []int
+		done, count int
+		want        int
+	}{
+		{"第一阶段过半", 10, []int{4}, 1, 2, 20},
+		{"第二阶段加上前面阶段的权重", 10, []int{4, 6}, 1, 2, 70},
+		{"处理数超过行数按阶段完成计", 10, []int{4}, 5, 2, 40},
+		{"行数为0按阶段完成计", 10, []int{4}, 0, 0, 40},
+		{"100%留给finish", 4, []int{4}, 2, 2, 99},
+		{"总权重为0", 0, []int{4}, 1, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter := &recordReporter{}
+			tracker := newProgressTracker(reporter, tt.total)
+			for _, weight := range tt.stages {
+				tracker.stage(weight)
+			}
+			tracker.step(tt.done, tt.count, "分析")
+			if len(reporter.infos) != 1 || reporter.infos[0].Num != tt.want {
+				t.Fatalf("step(%d, %d) 上报 %+v,期望 %d%%", tt.done, tt.count, reporter.infos, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressTrackerStepSkipsUnchanged(t *testing.T) {
+	reporter := &recordReporter{}
+	tracker := newProgressTracker(reporter, 100)
+	tracker.stage(100)
+	tracker.step(0, 1000, "读取")
+	tracker.step(1, 1000, "读取") // 百分比和文字都没变,不上报
+	tracker.step(1, 1000, "分析")
+	tracker.step(500, 1000, "分析")
+	tracker.finish("完成")
+
+	want := []ProgressInfo{{0, "读取"}, {0, "分析"}, {50, "分析"}, {100, "完成"}}
+	if len(reporter.infos) != len(want) {
+		t.Fatalf("上报 %+v,期望 %+v", reporter.infos, want)
+	}
+	for i := range want {
+		if reporter.infos[i] != want[i] {
+			t.Errorf("第 %d 次上报 %+v,期望 %+v", i+1, reporter.infos[i], want[i])
+		}
+	}
+}
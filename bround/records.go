@@ -0,0 +1,180 @@
+package bround
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// SaleRecord 是源数据中的一行配货记录,所有报表共用同一份解析结果
+type SaleRecord struct {
+	Date      time.Time
+	ProductID string
+	Customer  string
+	Quantity  int
+}
+
+// RejectedRow 是宽松模式下没能解析的源数据行
+type RejectedRow struct {
+	Row    int    `json:"row"`    // Excel 中的行号
+	Reason string `json:"reason"` // 无法解析的原因
+	Data   string `json:"data"`   // 该行原始内容
+}
+
+// LoadOptions 控制源文件的解析方式
+type LoadOptions struct {
+	Columns     ColumnMapping
+	DateLayouts []string // 日期列可接受的格式,为空时使用 DefaultDateLayouts
+	// Lenient 为 true 时,无法解析或列数不足的行记入 RejectedRow 继续分析;
+	// 为 false 时遇到无法解析的行直接报错,列数不足的行跳过
+	Lenient bool
+}
+
+func DefaultLoadOptions() LoadOptions {
+	return LoadOptions{Columns: DefaultColumnMapping(), DateLayouts: DefaultDateLayouts}
+}
+
+// loadSalesRecords 用行迭代器逐行读取第一个工作表,避免一次性把整张表读入内存。
+// 返回的 warnings 是不影响分析的提示,例如找不到表头时按固定列号读取
+func loadSalesRecords(filename string, opts LoadOptions, progress *progressTracker) (records []SaleRecord, rejected []RejectedRow, warnings []string, err error) {
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil, nil, fmt.Errorf("no sheets found in the Excel file")
+	}
+
+	rows, err := f.Rows(sheets[0])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading rows: %w", err)
+	}
+	defer rows.Close()
+
+	// 行迭代器不知道总行数,用工作表的尺寸估算进度
+	totalRows := sheetRowCount(filename, sheets[0])
+
+	layouts := opts.DateLayouts
+	if len(layouts) == 0 {
+		layouts = DefaultDateLayouts
+	}
+
+	var cols columnIndexes
+	for i := 0; rows.Next(); i++ {
+		if totalRows > 0 {
+			progress.step(i, totalRows, "正在读取文件")
+		}
+		// 取原始值:日期单元格是序列号,不受自定义显示格式影响,数量也不会带千分位
+		row, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error reading row %d: %w", i+1, err)
+		}
+		if i == 0 { // 表头行,确定各字段所在的列
+			if cols, err = opts.Columns.resolve(row); err != nil {
+				return nil, nil, nil, err
+			}
+			warnings = cols.warnings
+			continue
+		}
+		if isBlankRow(row) {
+			continue
+		}
+		reject := func(reason string) {
+			rejected = append(rejected, RejectedRow{Row: i + 1, Reason: reason, Data: strings.Join(row, " | ")})
+		}
+		if len(row) < cols.minLen {
+			if opts.Lenient {
+				reject(fmt.Sprintf("列数不足:需要 %d 列,实际 %d 列", cols.minLen, len(row)))
+			}
+			continue // Skip rows with insufficient data
+		}
+
+		date, err := parseDate(row[cols.date], layouts)
+		if err != nil {
+			if opts.Lenient {
+				reject(err.Error())
+				continue
+			}
+			return nil, nil, nil, fmt.Errorf("error parsing date in row %d: %w", i+1, err)
+		}
+
+		quantity, err := parseQuantity(row[cols.quantity])
+		if err != nil {
+			if opts.Lenient {
+				reject(err.Error())
+				continue
+			}
+			return nil, nil, nil, fmt.Errorf("error parsing quantity in row %d: %w", i+1, err)
+		}
+
+		records = append(records, SaleRecord{
+			Date:      date,
+			ProductID: row[cols.product],
+			Customer:  row[cols.customer],
+			Quantity:  quantity,
+		})
+	}
+	if err := rows.Error(); err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return records, rejected, warnings, nil
+}
+
+// parseQuantity 解析配货数量,接受 "12" 以及 "12.0" 这类整数值的小数写法
+func parseQuantity(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if quantity, err := strconv.Atoi(value); err == nil {
+		return quantity, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number != math.Trunc(number) {
+		return 0, fmt.Errorf("无法识别的数量 %q", value)
+	}
+	return int(number), nil
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRejectedRows 把宽松模式下跳过的行写入 "数据问题" 工作表
+func writeRejectedRows(f *excelize.File, sheetName string, rejected []RejectedRow) error {
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+
+	titles := []string{"行号", "原因", "原始数据"}
+	firstRow := writeReportHeader(f, sheetName, "以下行没能解析,未参与分析", titles)
+
+	for i, r := range rejected {
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), r.Row)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), r.Reason)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), r.Data)
+	}
+
+	return nil
+}
+
+func findLatestDate(records []SaleRecord) time.Time {
+	var latestDate time.Time
+	for _, record := range records {
+		if record.Date.After(latestDate) {
+			latestDate = record.Date
+		}
+	}
+	return latestDate
+}
@@ -0,0 +1,33 @@
+package bround
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"12", 12, false},
+		{" 7 ", 7, false},
+		{"12.0", 12, false},
+		{"-3", -3, false},
+		{"0", 0, false},
+		{"1.5", 0, true},
+		{"1,200", 0, true},
+		{"", 0, true},
+		{"十", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseQuantity(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseQuantity(%q) = %d,期望报错", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseQuantity(%q) = %d, %v,期望 %d", tt.value, got, err, tt.want)
+		}
+	}
+}
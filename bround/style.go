@@ -1,151 +1,110 @@
 package bround
 
 import (
-	"context"
 	"fmt"
 	"sort"
-	"strconv"
 	"time"
 
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"github.com/xuri/excelize/v2"
 )
 
-type StyleSale struct {
-	Date     time.Time
-	StyleID  string
-	Quantity int
-}
-
 type StyleReport struct {
-	StyleID    string
-	DailySales map[string]int
-	TotalSales int
+	StyleID    string         `json:"styleId"`
+	DailySales map[string]int `json:"dailySales"`
+	TotalSales int            `json:"totalSales"`
+	FirstDate  time.Time      `json:"firstDate"` // 统计范围内第一次有销量的日期
 }
 
-func CreateStyleReport(f *excelize.File, sheetName string, inputFilePath string, ctx context.Context) error {
-	// 1. 读取 Excel 文件
-	styleSales, err := readStyleSalesData(inputFilePath)
-	if err != nil {
-		//fmt.Println("Error reading Excel file:", err)
-		return err
-	}
-	runtime.EventsEmit(ctx, "progress", ProgressInfo{Num: 90, Text: "统计 货号 销量:正在分析数据"})
-	// 2. 处理销售数据
-	styleReports, dateRange := analyzeStyleSales(styleSales)
-	// 2.1. 按日期排序
-	latestDateStr := dateRange[len(dateRange)-1].Format("2006-01-02")
+func CreateStyleReport(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]StyleReport, error) {
+	// 1. 处理销售数据
+	progress.stage(weightAnalyze)
+	styleReports, dateRange := analyzeStyleSales(records, p, cfg.StyleMinLatestSales, progress)
+	// 1.1. 按报表日期的销量排序
+	latestDateStr := p.asOf.Format("2006-01-02")
 	sortedReports := sortReportsByLatestDateSales(styleReports, latestDateStr)
 
-	// 3. 生成报告
-	err = createStyleExcelReport(f, sheetName, sortedReports, dateRange)
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	trends := trendsFor(sortedReports, p, cfg)
+	styleDaily := make(map[string]map[string]int, len(sortedReports))
+	for _, report := range sortedReports {
+		styleDaily[report.StyleID] = report.DailySales
+	}
+	anomalies := detectAnomalies("货号", styleDaily, dateWindow{from: p.from, to: p.to}, cfg.AnomalyScore)
+	classes := styleClassMap(records, p, cfg)
+	err := createStyleExcelReport(f, sheetName, sortedReports, dateRange, trends, classes, anomalyCells(anomalies), p.rangeCaption(), progress)
 	if err != nil {
 		//fmt.Println("Error generating Excel report:", err)
-		return err
+		return nil, err
 	}
-
-	fmt.Println("Style sales report generated successfully.")
-	return nil
-}
-
-func readStyleSalesData(filename string) ([]StyleSale, error) {
-	f, err := excelize.OpenFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error opening file: %w", err)
+	if err := addStyleLineChart(f, sheetName, len(sortedReports), len(dateRange), cfg); err != nil {
+		return nil, err
 	}
-	defer f.Close()
 
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("no sheets found in the Excel file")
-	}
+	return sortedReports, nil
+}
 
-	rows, err := f.GetRows(sheets[0])
-	if err != nil {
-		return nil, fmt.Errorf("error reading rows: %w", err)
-	}
+// analyzeStyleSales 汇总统计范围内每个货号的逐日销量,只保留报表日期销量达到 minLatestSales 的货号
+func analyzeStyleSales(records []SaleRecord, p period, minLatestSales int, progress *progressTracker) ([]StyleReport, []time.Time) {
+	styleMap, dateSet := aggregateStyleSales(records, p, "统计 货号 销量:正在分析数据", progress)
 
-	var styleSales []StyleSale
-	for i, row := range rows {
-		if i == 0 { // Skip header row
-			continue
-		}
-		if len(row) < 9 {
-			continue // Skip rows with insufficient data
-		}
+	latestDateStr := p.asOf.Format("2006-01-02")
 
-		date, err := time.Parse("1/2/06 15:04", row[0])
-		if err != nil {
-			return nil, fmt.Errorf("error parsing date in row %d: %w", i+1, err)
+	var reports []StyleReport
+	for _, report := range styleMap {
+		if latestSale, exists := report.DailySales[latestDateStr]; exists && latestSale >= minLatestSales {
+			reports = append(reports, *report)
 		}
+	}
 
-		quantity, err := strconv.Atoi(row[8]) // 配货数量 is in the 9th column (index 8)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing quantity in row %d: %w", i+1, err)
-		}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].TotalSales > reports[j].TotalSales
+	})
 
-		styleSales = append(styleSales, StyleSale{
-			Date:     date,
-			StyleID:  row[3], // 货号 is in the 4th column (index 3)
-			Quantity: quantity,
-		})
+	var dateRange []time.Time
+	for dateStr := range dateSet {
+		date, _ := time.Parse("2006-01-02", dateStr)
+		dateRange = append(dateRange, date)
 	}
+	sort.Slice(dateRange, func(i, j int) bool {
+		return dateRange[i].Before(dateRange[j])
+	})
 
-	return styleSales, nil
+	return reports, dateRange
 }
 
-func analyzeStyleSales(styleSales []StyleSale) ([]StyleReport, []time.Time) {
+// aggregateStyleSales 汇总统计范围内每个货号的逐日销量,同时返回出现过的日期(2006-01-02)
+func aggregateStyleSales(records []SaleRecord, p period, text string, progress *progressTracker) (map[string]*StyleReport, map[string]bool) {
 	styleMap := make(map[string]*StyleReport)
 	dateSet := make(map[string]bool)
-	var latestDate time.Time
 
-	// Find the latest date
-	for _, sale := range styleSales {
-		if sale.Date.After(latestDate) {
-			latestDate = sale.Date
+	for i, sale := range records {
+		progress.step(i, len(records), text)
+		if !p.contains(sale.Date) {
+			continue
 		}
-	}
-
-	latestDateStr := latestDate.Format("2006-01-02")
-
-	for _, sale := range styleSales {
 		dateStr := sale.Date.Format("2006-01-02")
 		dateSet[dateStr] = true
+		day := startOfDay(sale.Date)
 
-		if report, exists := styleMap[sale.StyleID]; exists {
+		if report, exists := styleMap[sale.ProductID]; exists {
 			report.DailySales[dateStr] += sale.Quantity
 			report.TotalSales += sale.Quantity
+			if day.Before(report.FirstDate) {
+				report.FirstDate = day
+			}
 		} else {
-			styleMap[sale.StyleID] = &StyleReport{
-				StyleID:    sale.StyleID,
+			styleMap[sale.ProductID] = &StyleReport{
+				StyleID:    sale.ProductID,
 				DailySales: map[string]int{dateStr: sale.Quantity},
 				TotalSales: sale.Quantity,
+				FirstDate:  day,
 			}
 		}
 	}
-
-	var reports []StyleReport
-	for _, report := range styleMap {
-		if latestSale, exists := report.DailySales[latestDateStr]; exists && latestSale >= 10 {
-			reports = append(reports, *report)
-		}
-	}
-
-	sort.Slice(reports, func(i, j int) bool {
-		return reports[i].TotalSales > reports[j].TotalSales
-	})
-
-	var dateRange []time.Time
-	for dateStr := range dateSet {
-		date, _ := time.Parse("2006-01-02", dateStr)
-		dateRange = append(dateRange, date)
-	}
-	sort.Slice(dateRange, func(i, j int) bool {
-		return dateRange[i].Before(dateRange[j])
-	})
-
-	return reports, dateRange
+	return styleMap, dateSet
 }
+
 func sortReportsByLatestDateSales(reports []StyleReport, latestDateStr string) []StyleReport {
 	sort.Slice(reports, func(i, j int) bool {
 		salesI, existsI := reports[i].DailySales[latestDateStr]
@@ -161,7 +120,10 @@ func sortReportsByLatestDateSales(reports []StyleReport, latestDateStr string) [
 	})
 	return reports
 }
-func createStyleExcelReport(f *excelize.File, sheetName string, reports []StyleReport, dateRange []time.Time) error {
+
+// createStyleExcelReport 在总计之后写出每个货号的 7日均量、趋势斜率、趋势(见 StyleTrend)和 ABC 类别;
+// highlights 中的 货号|日期 单元格标为异常
+func createStyleExcelReport(f *excelize.File, sheetName string, reports []StyleReport, dateRange []time.Time, trends map[string]StyleTrend, classes map[string]string, highlights map[string]bool, caption string, progress *progressTracker) error {
 	// 创建新的工作表
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
@@ -172,29 +134,61 @@ func createStyleExcelReport(f *excelize.File, sheetName string, reports []StyleR
 	// Set headers
 	headers := []string{"货号"}
 	for _, date := range dateRange {
-		headers = append(headers, date.Format("01/02"))
+		headers = append(headers, dateTitle(date, dateRange[0], dateRange[len(dateRange)-1]))
 	}
-	headers = append(headers, "总计")
+	headers = append(headers, totalTitle)
+	totalCol := len(headers)
+	headers = append(headers, "7日均量", "趋势斜率", "趋势", "ABC")
+	firstRow := writeReportHeader(f, sheetName, caption, headers)
 
-	for col, header := range headers {
-		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
-		f.SetCellValue(sheetName, cell, header)
+	decimalStyle, err := f.NewStyle(&excelize.Style{NumFmt: 2}) // 0.00
+	if err != nil {
+		return err
+	}
+	anomalyStyle, err := newAnomalyStyle(f)
+	if err != nil {
+		return err
 	}
 
 	// Write data
-	for row, report := range reports {
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row+2), report.StyleID)
+	for i, report := range reports {
+		progress.step(i, len(reports), "统计 货号 销量:正在写入数据")
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), report.StyleID)
 
 		for col, date := range dateRange {
 			dateStr := date.Format("2006-01-02")
-			cell, _ := excelize.CoordinatesToCellName(col+2, row+2)
+			cell, _ := excelize.CoordinatesToCellName(col+2, row)
 			if quantity, exists := report.DailySales[dateStr]; exists {
 				f.SetCellValue(sheetName, cell, quantity)
 			}
+			if highlights[report.StyleID+"|"+dateStr] {
+				f.SetCellStyle(sheetName, cell, cell, anomalyStyle)
+			}
 		}
 
-		totalCell, _ := excelize.CoordinatesToCellName(len(headers), row+2)
-		f.SetCellValue(sheetName, totalCell, report.TotalSales)
+		totalCell, _ := excelize.CoordinatesToCellName(totalCol, row)
+		f.SetCellFormula(sheetName, totalCell, rangeFormula(sumFormula, 2, row, totalCol-1, row))
+
+		trend := trends[report.StyleID]
+		averageCell, _ := excelize.CoordinatesToCellName(totalCol+1, row)
+		slopeCell, _ := excelize.CoordinatesToCellName(totalCol+2, row)
+		classCell, _ := excelize.CoordinatesToCellName(totalCol+3, row)
+		f.SetCellValue(sheetName, averageCell, trend.MovingAverage)
+		f.SetCellValue(sheetName, slopeCell, trend.Slope)
+		f.SetCellValue(sheetName, classCell, trend.Class)
+		f.SetCellStyle(sheetName, averageCell, slopeCell, decimalStyle)
+		abcCell, _ := excelize.CoordinatesToCellName(totalCol+4, row)
+		f.SetCellValue(sheetName, abcCell, classes[report.StyleID])
+	}
+
+	// 合计行:每个日期列和总计列求和
+	if len(reports) > 0 {
+		totalRow := firstRow + len(reports)
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", totalRow), totalLabel)
+		if err := writeColumnTotals(f, sheetName, sumFormula, totalRow, 2, totalCol, firstRow, totalRow-1); err != nil {
+			return err
+		}
 	}
 
 	// Save file
@@ -1,138 +1,51 @@
 package bround
 
 import (
-	"context"
 	"fmt"
 	"sort"
-	"strconv"
-	"time"
 
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"github.com/xuri/excelize/v2"
 )
 
-type StyleSaleRecord struct {
-	Date      time.Time
-	ProductID string
-	Customer  string
-	Quantity  int
-}
-
 type StyleCustomerStat struct {
-	ProductID    string
-	Customer     string
-	DailySales   map[string]int
-	TotalSales   int
-	LastDaySales int
+	ProductID    string         `json:"productId"`
+	Customer     string         `json:"customer"`
+	DailySales   map[string]int `json:"dailySales"`
+	TotalSales   int            `json:"totalSales"`
+	LastDaySales int            `json:"lastDaySales"`
 }
 
-func getStyleSale(f *excelize.File, sheetName string, inputFilePath string, ctx context.Context) error {
-	// 1. 读取 Excel 文件
-	records, err := readStyleExcelFile(inputFilePath)
-	if err != nil {
-		//fmt.Println("Error reading Excel file:", err)
-		return err
-	}
-	runtime.EventsEmit(ctx, "progress", ProgressInfo{Num: 70, Text: "统计 客户+货号 销量:正在分析数据"})
-	// 2. 计算统计信息
-	stats, startDate, endDate, err := calculateStyleStats(records)
-	if err != nil {
-		//fmt.Println("Error calculating statistics:", err)
-		return err
+func getStyleSale(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]ProductStats, error) {
+	// 1. 计算统计信息
+	progress.stage(weightAnalyze)
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no records provided")
 	}
-
-	// 3. 生成新的 Excel 文件
-	err = generateStyleExcelReport(f, sheetName, stats, startDate, endDate)
+	salesMap := aggregateStyleCustomerSales(records, p, "统计 客户+货号 销量:正在分析数据", progress)
+	stats := calculateStyleStats(salesMap, p, cfg.StyleCustomerMinCustomerSales, cfg.StyleCustomerMinLatestSales)
+	// 按每个货号+客户的逐日销量判断异常,只标出该货号该客户当天的单元格
+	anomalies := detectAnomalies("货号+客户", styleCustomerDailySales(salesMap), dateWindow{from: p.from, to: p.to}, cfg.AnomalyScore)
+
+	// 2. 生成新的 Excel 文件
+	progress.stage(weightWrite)
+	err := generateStyleExcelReport(f, sheetName, stats, p, anomalyCells(anomalies), progress)
 	if err != nil {
 		//fmt.Println("Error generating Excel report:", err)
-		return err
-	}
-
-	fmt.Println("Style sales statistics report generated successfully.")
-	return nil
-}
-
-func readStyleExcelFile(filename string) ([]StyleSaleRecord, error) {
-	f, err := excelize.OpenFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error opening file: %w", err)
-	}
-	defer f.Close()
-
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("no sheets found in the Excel file")
-	}
-
-	rows, err := f.GetRows(sheets[0])
-	if err != nil {
-		return nil, fmt.Errorf("error reading rows: %w", err)
-	}
-
-	var records []StyleSaleRecord
-	for i, row := range rows {
-		if i == 0 { // Skip header row
-			continue
-		}
-		if len(row) < 12 {
-			continue // Skip rows with insufficient data
-		}
-
-		date, err := time.Parse("1/2/06 15:04", row[0])
-		if err != nil {
-			return nil, fmt.Errorf("error parsing date in row %d: %w", i+1, err)
-		}
-
-		quantity, err := strconv.Atoi(row[8]) // 配货数量 is in the 9th column (index 8)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing quantity in row %d: %w", i+1, err)
-		}
-
-		records = append(records, StyleSaleRecord{
-			Date:      date,
-			ProductID: row[3], // 货号 is in the 4th column (index 3)
-			Customer:  row[2], // 客户 is in the 3rd column (index 2)
-			Quantity:  quantity,
-		})
+		return nil, err
 	}
 
-	return records, nil
+	return stats, nil
 }
 
 type ProductStats struct {
-	ProductID     string
-	LastDaySales  int
-	CustomerStats []StyleCustomerStat
+	ProductID     string              `json:"productId"`
+	LastDaySales  int                 `json:"lastDaySales"`
+	CustomerStats []StyleCustomerStat `json:"customers"`
 }
 
-func calculateStyleStats(records []StyleSaleRecord) ([]ProductStats, time.Time, time.Time, error) {
-	if len(records) == 0 {
-		return nil, time.Time{}, time.Time{}, fmt.Errorf("no records provided")
-	}
-
-	salesMap := make(map[string]map[string]map[string]int)
-	var startDate, endDate time.Time
-
-	// Populate salesMap, startDate, endDate as before
-	for _, record := range records {
-		dateStr := record.Date.Format("2006-01-02")
-		if startDate.IsZero() || record.Date.Before(startDate) {
-			startDate = record.Date
-		}
-		if record.Date.After(endDate) {
-			endDate = record.Date
-		}
-
-		if _, exists := salesMap[record.ProductID]; !exists {
-			salesMap[record.ProductID] = make(map[string]map[string]int)
-		}
-		if _, exists := salesMap[record.ProductID][record.Customer]; !exists {
-			salesMap[record.ProductID][record.Customer] = make(map[string]int)
-		}
-		salesMap[record.ProductID][record.Customer][dateStr] += record.Quantity
-	}
-
-	lastDate := endDate.Format("2006-01-02")
+// calculateStyleStats 按 aggregateStyleCustomerSales 的结果筛选货号和客户,"最后一天" 指报表日期
+func calculateStyleStats(salesMap map[string]map[string]map[string]int, p period, minCustomerSales, minLatestSales int) []ProductStats {
+	lastDate := p.asOf.Format("2006-01-02")
 
 	var productStats []ProductStats
 
@@ -151,7 +64,7 @@ func calculateStyleStats(records []StyleSaleRecord) ([]ProductStats, time.Time,
 				}
 			}
 
-			if totalSales >= 20 {
+			if totalSales >= minCustomerSales {
 				customerStats = append(customerStats, StyleCustomerStat{
 					ProductID:    productID,
 					Customer:     customer,
@@ -162,7 +75,7 @@ func calculateStyleStats(records []StyleSaleRecord) ([]ProductStats, time.Time,
 			}
 		}
 
-		if lastDaySales >= 10 {
+		if lastDaySales >= minLatestSales {
 			// 首先按最后一天的销量降序排序
 			sort.Slice(customerStats, func(i, j int) bool {
 				return customerStats[i].LastDaySales > customerStats[j].LastDaySales
@@ -181,10 +94,63 @@ func calculateStyleStats(records []StyleSaleRecord) ([]ProductStats, time.Time,
 		return productStats[i].LastDaySales > productStats[j].LastDaySales
 	})
 
-	return productStats, startDate, endDate, nil
+	return productStats
+}
+
+// aggregateStyleCustomerSales 汇总统计范围内 货号 -> 客户 -> 日期(2006-01-02) 的销量
+func aggregateStyleCustomerSales(records []SaleRecord, p period, text string, progress *progressTracker) map[string]map[string]map[string]int {
+	salesMap := make(map[string]map[string]map[string]int)
+
+	// Populate salesMap
+	for i, record := range records {
+		progress.step(i, len(records), text)
+		if !p.contains(record.Date) {
+			continue
+		}
+		dateStr := record.Date.Format("2006-01-02")
+
+		if _, exists := salesMap[record.ProductID]; !exists {
+			salesMap[record.ProductID] = make(map[string]map[string]int)
+		}
+		if _, exists := salesMap[record.ProductID][record.Customer]; !exists {
+			salesMap[record.ProductID][record.Customer] = make(map[string]int)
+		}
+		salesMap[record.ProductID][record.Customer][dateStr] += record.Quantity
+	}
+	return salesMap
+}
+
+// customerDailySales 把 aggregateStyleCustomerSales 的结果按客户合并成 客户 -> 日期 的销量
+func customerDailySales(salesMap map[string]map[string]map[string]int) map[string]map[string]int {
+	customerDaily := make(map[string]map[string]int)
+	for _, customers := range salesMap {
+		for customer, dailySales := range customers {
+			if _, exists := customerDaily[customer]; !exists {
+				customerDaily[customer] = make(map[string]int)
+			}
+			for dateStr, quantity := range dailySales {
+				customerDaily[customer][dateStr] += quantity
+			}
+		}
+	}
+	return customerDaily
+}
+
+// styleCustomerDailySales 把 货号 -> 客户 -> 日期 的销量展开为 "货号|客户" -> 日期
+func styleCustomerDailySales(salesMap map[string]map[string]map[string]int) map[string]map[string]int {
+	pairDaily := make(map[string]map[string]int)
+	for productID, customers := range salesMap {
+		for customer, dailySales := range customers {
+			pairDaily[productID+"|"+customer] = dailySales
+		}
+	}
+	return pairDaily
 }
 
-func generateStyleExcelReport(f *excelize.File, sheetName string, productStats []ProductStats, startDate, endDate time.Time) error {
+// generateStyleExcelReport 中 highlights 的键为 货号|客户|日期,见 anomalyCells 和 styleCustomerDailySales
+func generateStyleExcelReport(f *excelize.File, sheetName string, productStats []ProductStats, p period, highlights map[string]bool, progress *progressTracker) error {
+	startDate, endDate := p.from, p.to
+
 	// Create new sheet
 	index, err := f.NewSheet(sheetName)
 	if err != nil {
@@ -196,19 +162,21 @@ func generateStyleExcelReport(f *excelize.File, sheetName string, productStats [
 	titles := []string{"货号", "客户"}
 	currentDate := startDate
 	for currentDate.Before(endDate) || currentDate.Equal(endDate) {
-		titles = append(titles, currentDate.Format("01/02"))
+		titles = append(titles, dateTitle(currentDate, startDate, endDate))
 		currentDate = currentDate.AddDate(0, 0, 1)
 	}
-	titles = append(titles, "总计")
+	titles = append(titles, totalTitle)
+	firstRow := writeReportHeader(f, sheetName, p.rangeCaption(), titles)
 
-	for i, title := range titles {
-		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-		f.SetCellValue(sheetName, cell, title)
+	anomalyStyle, err := newAnomalyStyle(f)
+	if err != nil {
+		return err
 	}
 
 	// 写入数据
-	row := 2
-	for _, product := range productStats {
+	row := firstRow
+	for i, product := range productStats {
+		progress.step(i, len(productStats), "统计 客户+货号 销量:正在写入数据")
 		startRow := row
 		for _, stat := range product.CustomerStats {
 			f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), product.ProductID)
@@ -218,24 +186,41 @@ func generateStyleExcelReport(f *excelize.File, sheetName string, productStats [
 			currentDate := startDate
 			for currentDate.Before(endDate) || currentDate.Equal(endDate) {
 				dateStr := currentDate.Format("2006-01-02")
+				cell, _ := excelize.CoordinatesToCellName(col, row)
 				if quantity, exists := stat.DailySales[dateStr]; exists {
-					cell, _ := excelize.CoordinatesToCellName(col, row)
 					f.SetCellValue(sheetName, cell, quantity)
 				}
+				// 当天没有配货也可能是异常(偏低),和月货号表一样标出
+				if highlights[product.ProductID+"|"+stat.Customer+"|"+dateStr] {
+					f.SetCellStyle(sheetName, cell, cell, anomalyStyle)
+				}
 				col++
 				currentDate = currentDate.AddDate(0, 0, 1)
 			}
 
 			totalCell, _ := excelize.CoordinatesToCellName(col, row)
-			f.SetCellValue(sheetName, totalCell, stat.TotalSales)
+			f.SetCellFormula(sheetName, totalCell, rangeFormula(sumFormula, 3, row, col-1, row))
 
 			row++
 		}
-		endRow := row - 1
+		if row == startRow {
+			continue
+		}
+
+		// 小计行,货号单元格一并合并
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), subtotalLabel)
+		if err := writeColumnTotals(f, sheetName, subtotalFormula, row, 3, len(titles), startRow, row-1); err != nil {
+			return err
+		}
+		f.MergeCell(sheetName, fmt.Sprintf("A%d", startRow), fmt.Sprintf("A%d", row))
+		row++
+	}
 
-		// 合并货号单元格
-		if startRow != endRow {
-			f.MergeCell(sheetName, fmt.Sprintf("A%d", startRow), fmt.Sprintf("A%d", endRow))
+	// 合计行,SUBTOTAL 会跳过各货号的小计
+	if row > firstRow {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), totalLabel)
+		if err := writeColumnTotals(f, sheetName, subtotalFormula, row, 3, len(titles), firstRow, row-1); err != nil {
+			return err
 		}
 	}
 
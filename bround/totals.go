@@ -0,0 +1,54 @@
+package bround
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	totalLabel    = "合计" // 整张表的合计行
+	subtotalLabel = "小计" // 每个货号的小计行
+	totalTitle    = "总计" // 逐日表格最后按行求和的列
+)
+
+// 合计和小计都写成公式,用户修改数字后会自动更新。totalFormula 把区域(如 B3:B10)写成求和公式
+type totalFormula func(ref string) string
+
+// sumFormula 返回 SUM(ref)
+func sumFormula(ref string) string {
+	return fmt.Sprintf("SUM(%s)", ref)
+}
+
+// subtotalFormula 返回 SUBTOTAL(9,ref),合计时会跳过范围内的其他 SUBTOTAL,有小计行的表用它避免重复计算
+func subtotalFormula(ref string) string {
+	return fmt.Sprintf("SUBTOTAL(9,%s)", ref)
+}
+
+// rangeFormula 返回 fn 作用于 fromCol/fromRow 到 toCol/toRow 的公式
+func rangeFormula(fn totalFormula, fromCol, fromRow, toCol, toRow int) string {
+	from, _ := excelize.CoordinatesToCellName(fromCol, fromRow)
+	to, _ := excelize.CoordinatesToCellName(toCol, toRow)
+	return fn(from + ":" + to)
+}
+
+// writeColumnTotals 在 row 行的 fromCol 到 toCol 列写出对 firstRow 到 lastRow 求和的公式
+func writeColumnTotals(f *excelize.File, sheetName string, fn totalFormula, row, fromCol, toCol, firstRow, lastRow int) error {
+	for col := fromCol; col <= toCol; col++ {
+		cell, _ := excelize.CoordinatesToCellName(col, row)
+		if err := f.SetCellFormula(sheetName, cell, rangeFormula(fn, col, firstRow, col, lastRow)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSummaryRow 判断前两列是否为 合计/小计 标签,格式化时加粗并排除在筛选范围之外
+func isSummaryRow(row []string) bool {
+	for i := 0; i < len(row) && i < 2; i++ {
+		if row[i] == totalLabel || row[i] == subtotalLabel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	TrendRising    = "上升"
+	TrendSteady    = "平稳"
+	TrendDeclining = "下降"
+)
+
+// StyleTrend 是一个货号截至报表日期的销售速度和趋势
+type StyleTrend struct {
+	StyleID       string  `json:"styleId"`
+	RecentSales   int     `json:"recentSales"`   // 趋势窗口内的总销量
+	MovingAverage float64 `json:"movingAverage"` // 最近7天的日均销量,没有销量的日子按 0 计
+	Slope         float64 `json:"slope"`         // 趋势窗口内逐日销量的线性回归斜率,单位 件/天
+	RelativeSlope float64 `json:"relativeSlope"` // Slope 相对窗口内日均销量的比例
+	Class         string  `json:"class"`         // TrendRising / TrendSteady / TrendDeclining
+}
+
+// trendWindow 返回截至报表日期的 days 天,不早于统计范围的起始日期
+func trendWindow(p period, days int) dateWindow {
+	from := p.asOf.AddDate(0, 0, -(days - 1))
+	if from.Before(p.from) {
+		from = p.from
+	}
+	return dateWindow{from: from, to: p.asOf}
+}
+
+// calculateTrend 根据逐日销量(键为 2006-01-02)计算 window 内的趋势,slopePercent 是判定上升/下降的相对斜率百分比
+func calculateTrend(styleID string, dailySales map[string]int, window dateWindow, slopePercent int) StyleTrend {
+	trend := StyleTrend{StyleID: styleID, Class: TrendSteady}
+
+	series := dailySeries(dailySales, window)
+	if len(series) == 0 {
+		return trend
+	}
+	for _, quantity := range series {
+		trend.RecentSales += int(quantity)
+	}
+
+	// 7日均量:窗口不足7天时按实际天数平均
+	recent := series
+	if len(recent) > 7 {
+		recent = recent[len(recent)-7:]
+	}
+	trend.MovingAverage = mean(recent)
+
+	// 最小二乘斜率,x 为第几天
+	n := float64(len(series))
+	xMean, yMean := (n-1)/2, mean(series)
+	var num, den float64
+	for i, y := range series {
+		dx := float64(i) - xMean
+		num += dx * (y - yMean)
+		den += dx * dx
+	}
+	if den > 0 {
+		trend.Slope = num / den
+	}
+	if yMean > 0 {
+		trend.RelativeSlope = trend.Slope / yMean
+	}
+
+	switch {
+	case trend.RelativeSlope*100 >= float64(slopePercent) && trend.Slope > 0:
+		trend.Class = TrendRising
+	case trend.RelativeSlope*100 <= -float64(slopePercent) && trend.Slope < 0:
+		trend.Class = TrendDeclining
+	}
+	return trend
+}
+
+// dailySeries 把逐日销量展开成 window 内每天一个值的序列,没有销量的日子为 0
+func dailySeries(dailySales map[string]int, window dateWindow) []float64 {
+	var series []float64
+	for date := window.from; !date.After(window.to); date = date.AddDate(0, 0, 1) {
+		series = append(series, float64(dailySales[date.Format("2006-01-02")]))
+	}
+	return series
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func getTrendReport(f *excelize.File, sheetName string, records []SaleRecord, p period, cfg AnalysisConfig, progress *progressTracker) ([]StyleTrend, error) {
+	window := trendWindow(p, cfg.TrendDays)
+
+	// 1. 汇总趋势窗口内每个货号的逐日销量并计算趋势
+	progress.stage(weightAnalyze)
+	styleMap, _ := aggregateStyleSales(records, period{asOf: p.asOf, from: window.from, to: window.to}, "统计 趋势:正在分析数据", progress)
+	trends := make([]StyleTrend, 0, len(styleMap))
+	for styleID, report := range styleMap {
+		trends = append(trends, calculateTrend(styleID, report.DailySales, window, cfg.TrendSlopePercent))
+	}
+	// 上升的在前,同类按相对斜率从高到低
+	classOrder := map[string]int{TrendRising: 0, TrendSteady: 1, TrendDeclining: 2}
+	sort.Slice(trends, func(i, j int) bool {
+		a, b := trends[i], trends[j]
+		if a.Class != b.Class {
+			return classOrder[a.Class] < classOrder[b.Class]
+		}
+		if a.RelativeSlope != b.RelativeSlope {
+			return a.RelativeSlope > b.RelativeSlope
+		}
+		return a.StyleID < b.StyleID
+	})
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  趋势窗口:%s  相对斜率超过 ±%d%% 判定为上升/下降", p.dateCaption(), window, cfg.TrendSlopePercent)
+	err := createTrendReport(f, sheetName, trends, window, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return trends, nil
+}
+
+func createTrendReport(f *excelize.File, sheetName string, trends []StyleTrend, window dateWindow, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	days := int(window.to.Sub(window.from).Hours()/24) + 1
+	titles := []string{"货号", fmt.Sprintf("%d日销量", days), "7日均量", "趋势斜率", "相对斜率", "趋势"}
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	decimalStyle, err := f.NewStyle(&excelize.Style{NumFmt: 2}) // 0.00
+	if err != nil {
+		return err
+	}
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	for i, trend := range trends {
+		progress.step(i, len(trends), "统计 趋势:正在写入数据")
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), trend.StyleID)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), trend.RecentSales)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), trend.MovingAverage)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), trend.Slope)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), trend.RelativeSlope)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), trend.Class)
+	}
+	if len(trends) > 0 {
+		lastRow := firstRow + len(trends) - 1
+		f.SetCellStyle(sheetName, fmt.Sprintf("C%d", firstRow), fmt.Sprintf("D%d", lastRow), decimalStyle)
+		f.SetCellStyle(sheetName, fmt.Sprintf("E%d", firstRow), fmt.Sprintf("E%d", lastRow), percentStyle)
+	}
+
+	return nil
+}
+
+// trendsFor 计算 月货号 中每个货号的趋势,键为货号
+func trendsFor(reports []StyleReport, p period, cfg AnalysisConfig) map[string]StyleTrend {
+	window := trendWindow(p, cfg.TrendDays)
+	trends := make(map[string]StyleTrend, len(reports))
+	for _, report := range reports {
+		trends[report.StyleID] = calculateTrend(report.StyleID, report.DailySales, window, cfg.TrendSlopePercent)
+	}
+	return trends
+}
@@ -0,0 +1,48 @@
+package bround
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateTrend(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	// series 从 8月1日起逐日展开成 dailySales
+	sales := func(series ...int) map[string]int {
+		daily := make(map[string]int)
+		for i, quantity := range series {
+			daily[day(1+i).Format("2006-01-02")] = quantity
+		}
+		return daily
+	}
+	threeDays := dateWindow{from: day(1), to: day(3)}
+	tests := []struct {
+		name        string
+		daily       map[string]int
+		window      dateWindow
+		wantClass   string
+		wantSlope   float64
+		wantRecent  int
+		wantAverage float64
+	}{
+		{"相对斜率正好达到阈值为上升", sales(95, 100, 105), threeDays, TrendRising, 5, 300, 100},
+		{"相对斜率低于阈值为平稳", sales(96, 100, 104), threeDays, TrendSteady, 4, 300, 100},
+		{"相对斜率正好达到负阈值为下降", sales(105, 100, 95), threeDays, TrendDeclining, -5, 300, 100},
+		{"相对斜率高于负阈值为平稳", sales(104, 100, 96), threeDays, TrendSteady, -4, 300, 100},
+		{"没有销量的日子按0计", sales(0, 0, 6), threeDays, TrendRising, 3, 6, 2},
+		{"窗口内没有销量为平稳", sales(), threeDays, TrendSteady, 0, 0, 0},
+		{"7日均量只取最近7天", sales(0, 0, 0, 7, 7, 7, 7, 7, 7, 7), dateWindow{from: day(1), to: day(10)}, TrendRising, 73.5 / 82.5, 49, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateTrend("A", tt.daily, tt.window, 5)
+			if got.Class != tt.wantClass || got.RecentSales != tt.wantRecent || math.Abs(got.MovingAverage-tt.wantAverage) > 1e-9 {
+				t.Errorf("calculateTrend = %+v,期望 %s、销量 %d、7日均量 %v", got, tt.wantClass, tt.wantRecent, tt.wantAverage)
+			}
+			if math.Abs(got.Slope-tt.wantSlope) > 1e-9 {
+				t.Errorf("斜率 %v,期望 %v", got.Slope, tt.wantSlope)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package bround
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// weekdayNames 按 time.Weekday 索引
+var weekdayNames = [7]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// weekdayOrder 是报表中星期列的顺序,周一在前
+var weekdayOrder = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// weekdayCompareWeeks 是同星期对比时往前看的周数
+const weekdayCompareWeeks = 4
+
+// WeekdayStat 是一个货号(或全部货号)按星期几的日均销量,以及报表日期与前几周同一星期几的对比
+type WeekdayStat struct {
+	StyleID    string     `json:"styleId"`
+	TotalSales int        `json:"totalSales"`
+	Averages   [7]float64 `json:"weekdayAverages"` // 按 time.Weekday 索引(周日为 0)的日均销量
+	AsOfSales  int        `json:"asOfSales"`
+	SameDayAvg float64    `json:"sameDayAverage"` // 前 weekdayCompareWeeks 周同一星期几的日均销量
+	HasSameDay bool       `json:"hasSameDay"`
+}
+
+// calculateWeekdayStat 从 window 内逐日的销量序列统计星期规律,序列最后一天是报表日期
+func calculateWeekdayStat(styleID string, series []float64, window dateWindow) WeekdayStat {
+	stat := WeekdayStat{StyleID: styleID}
+	var sums, counts [7]float64
+	for i, value := range series {
+		weekday := window.from.AddDate(0, 0, i).Weekday()
+		sums[weekday] += value
+		counts[weekday]++
+		stat.TotalSales += int(value)
+	}
+	for weekday := range stat.Averages {
+		if counts[weekday] > 0 {
+			stat.Averages[weekday] = sums[weekday] / counts[weekday]
+		}
+	}
+
+	// 报表日期之前至少有一个同星期几的日子才能对比,用星期平均预测报表日期当天的销量
+	if len(series) == 0 {
+		return stat
+	}
+	stat.AsOfSales = int(series[len(series)-1])
+	if len(series) > 7 {
+		prior := series[:len(series)-1]
+		stat.SameDayAvg = WeekdayAverage{Weeks: weekdayCompareWeeks}.Forecast(prior, window.to.AddDate(0, 0, -1), 1)[0]
+		stat.HasSameDay = true
+	}
+	return stat
+}
+
+func getWeekdayReport(f *excelize.File, sheetName string, records []SaleRecord, p period, progress *progressTracker) ([]WeekdayStat, error) {
+	window := dateWindow{from: p.from, to: p.asOf}
+
+	// 1. 按货号和全部货号统计星期规律
+	progress.stage(weightAnalyze)
+	styleMap, _ := aggregateStyleSales(records, period{asOf: p.asOf, from: window.from, to: window.to}, "统计 星期规律:正在分析数据", progress)
+	overall := make([]float64, int(window.to.Sub(window.from).Hours()/24)+1)
+	stats := make([]WeekdayStat, 0, len(styleMap))
+	for styleID, report := range styleMap {
+		series := dailySeries(report.DailySales, window)
+		for i, value := range series {
+			overall[i] += value
+		}
+		stats = append(stats, calculateWeekdayStat(styleID, series, window))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalSales != stats[j].TotalSales {
+			return stats[i].TotalSales > stats[j].TotalSales
+		}
+		return stats[i].StyleID < stats[j].StyleID
+	})
+	stats = append([]WeekdayStat{calculateWeekdayStat("全部", overall, window)}, stats...)
+
+	// 2. 生成报告
+	progress.stage(weightWrite)
+	caption := fmt.Sprintf("%s  星期日均:%s  同日对比:报表日期(%s)对比前%d周同一天的日均销量",
+		p.dateCaption(), window, weekdayNames[p.asOf.Weekday()], weekdayCompareWeeks)
+	err := createWeekdayReport(f, sheetName, stats, caption, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func createWeekdayReport(f *excelize.File, sheetName string, stats []WeekdayStat, caption string, progress *progressTracker) error {
+	// 创建新的工作表
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %w", err)
+	}
+	f.SetActiveSheet(index)
+
+	titles := []string{"货号"}
+	for _, weekday := range weekdayOrder {
+		titles = append(titles, weekdayNames[weekday])
+	}
+	titles = append(titles, "报表日期销量", fmt.Sprintf("前%d周同日均量", weekdayCompareWeeks), "同日对比")
+	firstRow := writeReportHeader(f, sheetName, caption, titles)
+
+	decimalStyle, err := f.NewStyle(&excelize.Style{NumFmt: 2}) // 0.00
+	if err != nil {
+		return err
+	}
+	percentStyle, err := f.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+
+	for i, stat := range stats {
+		progress.step(i, len(stats), "统计 星期规律:正在写入数据")
+		row := i + firstRow
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), stat.StyleID)
+		for col, weekday := range weekdayOrder {
+			cell, _ := excelize.CoordinatesToCellName(col+2, row)
+			f.SetCellValue(sheetName, cell, stat.Averages[weekday])
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), stat.AsOfSales)
+		if stat.HasSameDay {
+			f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), stat.SameDayAvg)
+			if stat.SameDayAvg > 0 {
+				f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), (float64(stat.AsOfSales)-stat.SameDayAvg)/stat.SameDayAvg)
+			}
+		}
+	}
+	if len(stats) > 0 {
+		lastRow := firstRow + len(stats) - 1
+		f.SetCellStyle(sheetName, fmt.Sprintf("B%d", firstRow), fmt.Sprintf("H%d", lastRow), decimalStyle)
+		f.SetCellStyle(sheetName, fmt.Sprintf("J%d", firstRow), fmt.Sprintf("J%d", lastRow), decimalStyle)
+		f.SetCellStyle(sheetName, fmt.Sprintf("K%d", firstRow), fmt.Sprintf("K%d", lastRow), percentStyle)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+// analyzer 是销售报表分析的命令行版本,不依赖 Wails 窗口,适合在服务器或 cron 中运行。
+//
+// 用法:
+//
+//	analyzer [-config 分析设置.json] [-sheets overview,daily,customer,style-customer,style,month-compare,customer-rank,churn,new,trend,forecast,weekday,anomaly,abc,hourly]
+//	         [-date 2006-01-02] [-from 2006-01-02] [-to 2006-01-02] [-progress terminal|log|none] [-columns 列配置.json] [-date-layout 2006-01-02 ...] [-lenient]
+//	         [-format xlsx|csv|json] <输入文件.xlsx> [输出文件]
+//	analyzer -write-config 分析设置.json
+//	analyzer -write-columns 列配置.json
+//
+// 报表日期默认取数据中的最新日期,可以用 -date 指定;-from/-to 限定月货号等多日报表的统计范围,
+// 例如 -from 2024-09-01 -to 2024-09-30 从更大的导出中生成 9 月的汇总。
+// 未指定输出文件时,结果写到输入文件旁的 "<文件名>_分析完成_<报表日期>.xlsx"。
+// -format csv 时每张表写成一个 UTF-8(带 BOM)的 CSV 文件,输出路径是存放这些文件的目录,
+// 再次写入同一目录时只替换上次生成的文件;-format json 时各报表的计算结果写到一个 JSON 文件,
+// 字段名固定,日期为 ISO 格式,合计和小计行不输出。
+// -write-config 写出默认分析设置(报表和各项筛选阈值),修改后用 -config 加载;-sheets 会覆盖设置中的报表列表。
+// -write-columns 写出默认列配置,修改后用 -columns 加载即可适配调整过列顺序或表头的导出文件。
+// -date-layout 可以重复指定,使用 Go 的时间格式写法,会替换默认的日期格式列表。
+// -lenient 时无法解析的行不会中断分析,而是写入输出文件的 "数据问题" 工作表。
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	e "ExcelAnalyzer/bround"
+)
+
+// 退出码
+const (
+	exitOK               = 0
+	exitFailed           = 1 // 读取、分析或保存失败
+	exitUsage            = 2 // 参数错误
+	exitInsufficientData = 3 // 数据天数不足
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	fs := flag.NewFlagSet("analyzer", flag.ContinueOnError)
+	config := fs.String("config", "", "分析设置文件(JSON),包含报表列表和筛选阈值")
+	writeConfig := fs.String("write-config", "", "把默认分析设置写到指定文件后退出")
+	sheets := fs.String("sheets", "", "要生成的报表,逗号分隔,默认全部: "+strings.Join(e.AllSheets, ", "))
+	format := fs.String("format", "", "输出格式: "+strings.Join(e.OutputFormats, ", ")+",默认取分析设置中的 outputFormat(xlsx)")
+	var period e.ReportPeriod
+	fs.StringVar(&period.AsOf, "date", "", "报表日期(2006-01-02),默认取 -to 或数据中的最新日期")
+	fs.StringVar(&period.From, "from", "", "多日报表的起始日期(2006-01-02),默认取数据中的最早日期")
+	fs.StringVar(&period.To, "to", "", "多日报表的结束日期(2006-01-02),默认取报表日期")
+	progress := fs.String("progress", "terminal", "进度输出方式: terminal(进度条), log(逐行日志), none(不输出)")
+	columns := fs.String("columns", "", "列配置文件(JSON),按表头名称匹配各字段所在的列")
+	writeColumns := fs.String("write-columns", "", "把默认列配置写到指定文件后退出")
+	lenient := fs.Bool("lenient", false, "宽松模式:跳过无法解析的行并写入 \"数据问题\" 工作表")
+	var dateLayouts []string
+	fs.Func("date-layout", "日期格式(Go 时间格式,可重复指定),替换默认格式列表", func(layout string) error {
+		dateLayouts = append(dateLayouts, layout)
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: analyzer [选项] <输入文件.xlsx> [输出文件]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *writeConfig != "" {
+		if err := e.SaveAnalysisConfig(*writeConfig, e.DefaultAnalysisConfig()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		return exitOK
+	}
+	if *writeColumns != "" {
+		if err := e.SaveColumnMapping(*writeColumns, e.DefaultColumnMapping()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailed
+		}
+		return exitOK
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	inputFilePath := fs.Arg(0)
+	outFilePath := fs.Arg(1) // 为空时按报表日期生成
+
+	cfg := e.DefaultAnalysisConfig()
+	if *config != "" {
+		loaded, err := e.LoadAnalysisConfig(*config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		cfg = loaded
+	}
+
+	if *sheets != "" {
+		var selected []string
+		for _, sheet := range strings.Split(*sheets, ",") {
+			if sheet = strings.TrimSpace(sheet); sheet == "" {
+				continue
+			}
+			if !e.IsKnownSheet(sheet) {
+				fmt.Fprintln(os.Stderr, "未知的报表:", sheet)
+				return exitUsage
+			}
+			selected = append(selected, sheet)
+		}
+		cfg.Sheets = selected
+	}
+	if *format != "" {
+		if _, err := e.NewReportWriter(*format); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		cfg.OutputFormat = *format
+	}
+	if err := period.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	cfg.Period = period
+
+	opts := e.DefaultLoadOptions()
+	if *columns != "" {
+		mapping, err := e.LoadColumnMapping(*columns)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		opts.Columns = mapping
+	}
+	if len(dateLayouts) > 0 {
+		opts.DateLayouts = dateLayouts
+	}
+	opts.Lenient = *lenient
+	cfg.Load = opts
+
+	var reporter e.ProgressReporter
+	switch *progress {
+	case "terminal":
+		reporter = e.NewTerminalReporter(os.Stderr)
+	case "log":
+		reporter = e.NewLogReporter(log.New(os.Stderr, "", log.LstdFlags))
+	case "none":
+		reporter = e.NopReporter{}
+	default:
+		fmt.Fprintln(os.Stderr, "未知的进度输出方式:", *progress)
+		return exitUsage
+	}
+
+	summary, err := e.Main_go(inputFilePath, outFilePath, cfg, reporter)
+	for _, warning := range summary.Warnings {
+		fmt.Fprintln(os.Stderr, "提示:", warning)
+	}
+	printRejected(summary.Rejected)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "分析失败:", err)
+		if errors.Is(err, e.ErrInsufficientData) {
+			return exitInsufficientData
+		}
+		return exitFailed
+	}
+	fmt.Fprintln(os.Stderr, "分析完成:", summary.OutputPath)
+	return exitOK
+}
+
+// printRejected 列出宽松模式下跳过的行,行数太多时只列出前几行
+func printRejected(rejected []e.RejectedRow) {
+	const limit = 20
+	if len(rejected) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%d 行数据无法解析,详见输出文件的 \"数据问题\" 工作表:\n", len(rejected))
+	for i, r := range rejected {
+		if i == limit {
+			fmt.Fprintf(os.Stderr, "  ……其余 %d 行省略\n", len(rejected)-limit)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "  第 %d 行: %s\n", r.Row, r.Reason)
+	}
+}
@@ -2,14 +2,21 @@ import React, { useState, useEffect } from 'react'
 import { Button } from "@/components/ui/button"
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card"
 import { Progress } from "@/components/ui/progress"
-import { FileSpreadsheet, BarChart2, Save } from "lucide-react"
+import { Input } from "@/components/ui/input"
+import { FileSpreadsheet, BarChart2, Save, AlertTriangle, Settings } from "lucide-react"
+import SettingsPanel from "@/components/SettingsPanel"
 import { AnalyzeExcel, SaveExcel, OpenFileDialog } from '../wailsjs/go/main/App'
+import { bround } from '../wailsjs/go/models'
 import { EventsOn,EventsOff } from '../wailsjs/runtime'
 
 export default function Component() { 
   const [filePath, setFilePath] = useState('')
   const [isAnalyzing, setIsAnalyzing] = useState(false)
   const [isAnalyzed, setIsAnalyzed] = useState(false)
+  const [summary, setSummary] = useState<bround.Summary | null>(null)
+  const [showSettings, setShowSettings] = useState(false)
+  // 留空的日期由后端按数据自动确定
+  const [period, setPeriod] = useState({ asOf: '', from: '', to: '' })
   const [progress, setProgress] = useState({
     num:0,
     text:"初始化中..."
@@ -33,8 +40,10 @@ export default function Component() {
       num:0,
       text:"初始化中..."
     })
+    setSummary(null)
     try {
-      await AnalyzeExcel(filePath)
+      const result = await AnalyzeExcel(filePath, period)
+      setSummary(result)
       setIsAnalyzed(true)
       alert('分析完成!')
     } catch (error) {
@@ -74,9 +83,15 @@ export default function Component() {
     <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-purple-400 via-pink-500 to-red-400">
       <Card className="w-full max-w-md shadow-2xl bg-white bg-opacity-90 backdrop-blur-sm border-4 border-transparent" style={{ borderImage: 'linear-gradient(to right, #6366f1, #ec4899) 1' }}>
         <CardHeader className="bg-gradient-to-r from-indigo-500 to-purple-600 text-white rounded-t-lg">
-          <CardTitle className="text-3xl font-bold text-center">销售数据分析</CardTitle>
+          <CardTitle className="text-3xl font-bold text-center relative">
+            销售数据分析
+            <button onClick={() => setShowSettings(!showSettings)} className="absolute right-0 top-1/2 -translate-y-1/2" title="分析设置">
+              <Settings className="h-6 w-6 text-indigo-100 hover:text-white" />
+            </button>
+          </CardTitle>
         </CardHeader>
         <CardContent className="mt-6 space-y-6 p-6">
+          {showSettings && <SettingsPanel onClose={() => setShowSettings(false)} />}
           <Button onClick={handleFileSelect} className="w-full bg-gradient-to-r from-blue-500 to-cyan-500 hover:from-blue-600 hover:to-cyan-600 text-white shadow-lg transition-all duration-300">
             <FileSpreadsheet className="mr-2 h-5 w-5 text-blue-200" />
             选择原始数据文件
@@ -87,6 +102,23 @@ export default function Component() {
               所选文件:{filePath}
             </p>
           )}
+          <div className="grid grid-cols-3 gap-2 text-xs text-gray-600">
+            <label className="space-y-1">
+              <span>报表日期</span>
+              <Input type="date" className="h-8 px-1 text-xs" value={period.asOf}
+                onChange={(e) => setPeriod({ ...period, asOf: e.target.value })} />
+            </label>
+            <label className="space-y-1">
+              <span>统计起始</span>
+              <Input type="date" className="h-8 px-1 text-xs" value={period.from}
+                onChange={(e) => setPeriod({ ...period, from: e.target.value })} />
+            </label>
+            <label className="space-y-1">
+              <span>统计结束</span>
+              <Input type="date" className="h-8 px-1 text-xs" value={period.to}
+                onChange={(e) => setPeriod({ ...period, to: e.target.value })} />
+            </label>
+          </div>
           <Button 
             onClick={handleAnalyze} 
             disabled={!filePath || isAnalyzing} 
@@ -106,6 +138,34 @@ export default function Component() {
               <div className="text-xs text-center mt-1 text-gray-600">{progress.text}</div>
             </div>
           )}
+          {summary && (
+            <p className="text-sm text-gray-600 bg-gray-100 p-2 rounded-md">
+              报表日期:{summary.reportDate},统计范围:{summary.from} 至 {summary.to},共 {summary.records} 条记录
+            </p>
+          )}
+          {summary && summary.warnings?.length > 0 && (
+            <div className="text-sm text-amber-800 bg-amber-50 border border-amber-200 p-2 rounded-md space-y-1">
+              {summary.warnings.map((w) => (
+                <p key={w} className="flex items-center">
+                  <AlertTriangle className="mr-1 h-4 w-4" />
+                  {w}
+                </p>
+              ))}
+            </div>
+          )}
+          {summary && summary.rejected?.length > 0 && (
+            <div className="text-sm text-amber-800 bg-amber-50 border border-amber-200 p-2 rounded-md space-y-1">
+              <p className="flex items-center font-medium">
+                <AlertTriangle className="mr-1 h-4 w-4" />
+                {summary.rejected.length} 行数据无法解析,已跳过并写入“数据问题”工作表
+              </p>
+              <ul className="text-xs max-h-24 overflow-y-auto">
+                {summary.rejected.slice(0, 20).map((r) => (
+                  <li key={r.row}>第 {r.row} 行:{r.reason}</li>
+                ))}
+              </ul>
+            </div>
+          )}
           {isAnalyzed && (
             <Button onClick={handleSave} className="w-full bg-gradient-to-r from-pink-500 to-rose-500 hover:from-pink-600 hover:to-rose-600 text-white shadow-lg transition-all duration-300">
               <Save className="mr-2 h-5 w-5 text-pink-200" />
@@ -0,0 +1,155 @@
+import React, { useState, useEffect } from 'react'
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { GetConfig, SaveConfig, DefaultConfig } from '../../wailsjs/go/main/App'
+import { bround } from '../../wailsjs/go/models'
+
+const sheetLabels: Record<string, string> = {
+  'overview': '总览',
+  'daily': '销量',
+  'customer': '客户',
+  'style-customer': '月货号+客户',
+  'style': '月货号',
+  'month-compare': '月同期对比',
+  'customer-rank': '客户排名',
+  'churn': '客户流失',
+  'new': '新品新客',
+  'trend': '趋势',
+  'forecast': '预测',
+  'weekday': '星期规律',
+  'anomaly': '异常',
+  'abc': 'ABC分类',
+  'hourly': '时段',
+}
+
+const forecastMethods: Record<string, string> = {
+  'weekday': '星期平均',
+  'smoothing': '指数平滑',
+}
+
+const chartPlacements: Record<string, string> = {
+  'right': '数据右侧',
+  'below': '数据下方',
+}
+
+const outputFormats: Record<string, string> = {
+  'xlsx': 'Excel 工作簿',
+  'csv': 'CSV(每张表一个文件)',
+  'json': 'JSON',
+}
+
+const thresholdFields: { key: keyof bround.AnalysisConfig, label: string }[] = [
+  { key: 'customerMinProductSales', label: '客户:货号当日销量至少' },
+  { key: 'styleCustomerMinCustomerSales', label: '月货号+客户:客户期间销量至少' },
+  { key: 'styleCustomerMinLatestSales', label: '月货号+客户:货号最新一天销量至少' },
+  { key: 'styleMinLatestSales', label: '月货号:货号最新一天销量至少' },
+  { key: 'churnMinActiveDays', label: '客户流失:此前配货天数至少' },
+  { key: 'churnDormantDays', label: '客户流失:连续未配货天数' },
+  { key: 'churnWeeklyDropPercent', label: '客户流失:7日销量下降超过(%)' },
+  { key: 'newWithinDays', label: '新品新客:最近几天内首次出现' },
+  { key: 'trendDays', label: '趋势:计算斜率的天数' },
+  { key: 'trendSlopePercent', label: '趋势:相对斜率超过(%)' },
+  { key: 'anomalyScore', label: '异常:偏离分数达到' },
+  { key: 'abcAPercent', label: 'ABC分类:A类累计占比(%)' },
+  { key: 'abcBPercent', label: 'ABC分类:A+B类累计占比(%)' },
+  { key: 'chartTopN', label: '图表:显示前几名(0 不画图)' },
+]
+
+export default function SettingsPanel({ onClose }: { onClose: () => void }) {
+  const [config, setConfig] = useState<bround.AnalysisConfig | null>(null)
+  const [error, setError] = useState('')
+
+  useEffect(() => {
+    GetConfig().then(setConfig)
+  }, [])
+
+  if (!config) return null
+
+  const toggleSheet = (sheet: string) => {
+    const sheets = config.sheets.includes(sheet)
+      ? config.sheets.filter((s) => s !== sheet)
+      : Object.keys(sheetLabels).filter((s) => s === sheet || config.sheets.includes(s))
+    setConfig({ ...config, sheets })
+  }
+
+  const setThreshold = (key: keyof bround.AnalysisConfig, value: string) => {
+    setConfig({ ...config, [key]: Number(value) || 0 })
+  }
+
+  const handleSave = async () => {
+    try {
+      await SaveConfig(config)
+      setError('')
+      onClose()
+    } catch (err) {
+      setError(String(err))
+    }
+  }
+
+  const handleReset = async () => {
+    setConfig(await DefaultConfig())
+  }
+
+  return (
+    <div className="space-y-4 text-sm text-gray-700">
+      <div>
+        <p className="font-medium mb-2">生成的报表</p>
+        <div className="grid grid-cols-2 gap-2">
+          {Object.entries(sheetLabels).map(([sheet, label]) => (
+            <label key={sheet} className="flex items-center space-x-2">
+              <input type="checkbox" checked={config.sheets.includes(sheet)} onChange={() => toggleSheet(sheet)} />
+              <span>{label}</span>
+            </label>
+          ))}
+        </div>
+      </div>
+      <label className="flex items-center space-x-2">
+        <input type="checkbox" checked={config.dailyWeekCompare}
+          onChange={(e) => setConfig({ ...config, dailyWeekCompare: e.target.checked })} />
+        <span>销量表与前7日对比(需要至少14天数据)</span>
+      </label>
+      <label className="flex items-center justify-between space-x-2">
+        <span>预测方法</span>
+        <select className="border rounded h-8 px-2" value={config.forecastMethod}
+          onChange={(e) => setConfig({ ...config, forecastMethod: e.target.value })}>
+          {Object.entries(forecastMethods).map(([method, label]) => (
+            <option key={method} value={method}>{label}</option>
+          ))}
+        </select>
+      </label>
+      <label className="flex items-center justify-between space-x-2">
+        <span>图表位置</span>
+        <select className="border rounded h-8 px-2" value={config.chartPlacement}
+          onChange={(e) => setConfig({ ...config, chartPlacement: e.target.value })}>
+          {Object.entries(chartPlacements).map(([placement, label]) => (
+            <option key={placement} value={placement}>{label}</option>
+          ))}
+        </select>
+      </label>
+      <label className="flex items-center justify-between space-x-2">
+        <span>输出格式</span>
+        <select className="border rounded h-8 px-2" value={config.outputFormat}
+          onChange={(e) => setConfig({ ...config, outputFormat: e.target.value })}>
+          {Object.entries(outputFormats).map(([format, label]) => (
+            <option key={format} value={format}>{label}</option>
+          ))}
+        </select>
+      </label>
+      <div className="space-y-2">
+        <p className="font-medium">筛选阈值</p>
+        {thresholdFields.map(({ key, label }) => (
+          <label key={key} className="flex items-center justify-between space-x-2">
+            <span>{label}</span>
+            <Input type="number" min={0} className="w-24 h-8" value={config[key] as number}
+              onChange={(e) => setThreshold(key, e.target.value)} />
+          </label>
+        ))}
+      </div>
+      {error && <p className="text-red-600">{error}</p>}
+      <div className="flex space-x-2">
+        <Button onClick={handleSave} className="flex-1 bg-gradient-to-r from-indigo-500 to-purple-600 text-white">保存设置</Button>
+        <Button onClick={handleReset} variant="outline" className="flex-1">恢复默认</Button>
+      </div>
+    </div>
+  )
+}
@@ -1,10 +1,17 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {bround} from '../models';
 
-export function AnalyzeExcel(arg1:string):Promise<void>;
+export function AnalyzeExcel(arg1:string,arg2:bround.ReportPeriod):Promise<bround.Summary>;
+
+export function DefaultConfig():Promise<bround.AnalysisConfig>;
+
+export function GetConfig():Promise<bround.AnalysisConfig>;
 
 export function Greet(arg1:string):Promise<string>;
 
 export function OpenFileDialog():Promise<string>;
 
+export function SaveConfig(arg1:bround.AnalysisConfig):Promise<void>;
+
 export function SaveExcel():Promise<void>;
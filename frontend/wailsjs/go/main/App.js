@@ -2,8 +2,16 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function AnalyzeExcel(arg1) {
-  return window['go']['main']['App']['AnalyzeExcel'](arg1);
+export function AnalyzeExcel(arg1, arg2) {
+  return window['go']['main']['App']['AnalyzeExcel'](arg1, arg2);
+}
+
+export function DefaultConfig() {
+  return window['go']['main']['App']['DefaultConfig']();
+}
+
+export function GetConfig() {
+  return window['go']['main']['App']['GetConfig']();
 }
 
 export function Greet(arg1) {
@@ -14,6 +22,10 @@ export function OpenFileDialog() {
   return window['go']['main']['App']['OpenFileDialog']();
 }
 
+export function SaveConfig(arg1) {
+  return window['go']['main']['App']['SaveConfig'](arg1);
+}
+
 export function SaveExcel() {
   return window['go']['main']['App']['SaveExcel']();
 }
@@ -0,0 +1,127 @@
+export namespace bround {
+	
+	export class AnalysisConfig {
+	    sheets: string[];
+	    dailyWeekCompare: boolean;
+	    customerMinProductSales: number;
+	    styleCustomerMinCustomerSales: number;
+	    styleCustomerMinLatestSales: number;
+	    styleMinLatestSales: number;
+	    churnMinActiveDays: number;
+	    churnDormantDays: number;
+	    churnWeeklyDropPercent: number;
+	    newWithinDays: number;
+	    trendDays: number;
+	    trendSlopePercent: number;
+	    forecastMethod: string;
+	    anomalyScore: number;
+	    abcAPercent: number;
+	    abcBPercent: number;
+	    chartTopN: number;
+	    chartPlacement: string;
+	    outputFormat: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnalysisConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sheets = source["sheets"];
+	        this.dailyWeekCompare = source["dailyWeekCompare"];
+	        this.customerMinProductSales = source["customerMinProductSales"];
+	        this.styleCustomerMinCustomerSales = source["styleCustomerMinCustomerSales"];
+	        this.styleCustomerMinLatestSales = source["styleCustomerMinLatestSales"];
+	        this.styleMinLatestSales = source["styleMinLatestSales"];
+	        this.churnMinActiveDays = source["churnMinActiveDays"];
+	        this.churnDormantDays = source["churnDormantDays"];
+	        this.churnWeeklyDropPercent = source["churnWeeklyDropPercent"];
+	        this.newWithinDays = source["newWithinDays"];
+	        this.trendDays = source["trendDays"];
+	        this.trendSlopePercent = source["trendSlopePercent"];
+	        this.forecastMethod = source["forecastMethod"];
+	        this.anomalyScore = source["anomalyScore"];
+	        this.abcAPercent = source["abcAPercent"];
+	        this.abcBPercent = source["abcBPercent"];
+	        this.chartTopN = source["chartTopN"];
+	        this.chartPlacement = source["chartPlacement"];
+	        this.outputFormat = source["outputFormat"];
+	    }
+	}
+	export class RejectedRow {
+	    row: number;
+	    reason: string;
+	    data: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RejectedRow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.row = source["row"];
+	        this.reason = source["reason"];
+	        this.data = source["data"];
+	    }
+	}
+	export class ReportPeriod {
+	    asOf: string;
+	    from: string;
+	    to: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReportPeriod(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.asOf = source["asOf"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	    }
+	}
+	export class Summary {
+	    records: number;
+	    rejected: RejectedRow[];
+	    warnings: string[];
+	    reportDate: string;
+	    from: string;
+	    to: string;
+	    outputPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.records = source["records"];
+	        this.rejected = this.convertValues(source["rejected"], RejectedRow);
+	        this.warnings = source["warnings"];
+	        this.reportDate = source["reportDate"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.outputPath = source["outputPath"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+